@@ -1,3 +0,0 @@
-module translator
-
-go 1.19
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+
+	"translator/assembler"
+)
+
+// runAssemble assembles .asm files into .hack files next to them
+func runAssemble(args []string) {
+	if len(args) < 1 {
+		log.Fatal("usage: translator assemble <file.asm> ...")
+	}
+	for _, asmPath := range args {
+		if err := assembleFile(asmPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func assembleFile(asmPath string) error {
+	file, err := os.Open(asmPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	code, err := assembler.Assemble(bufio.NewReader(file), asmPath)
+	if err != nil {
+		return err
+	}
+	return writeHackFile(strings.TrimSuffix(asmPath, ".asm")+".hack", code)
+}
+
+func writeHackFile(hackPath string, code []uint16) error {
+	out, err := os.Create(hackPath)
+	if err != nil {
+		return err
+	}
+	if err := assembler.WriteHack(out, code); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
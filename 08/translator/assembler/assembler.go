@@ -0,0 +1,253 @@
+// Package assembler translates Hack assembly into Hack machine code.
+package assembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// first RAM address given to variables
+const variableBase = 16
+
+// largest value an A-instruction can load
+const maxAddress = 1<<15 - 1
+
+var predefinedSymbols = map[string]uint16{
+	"SP":     0,
+	"LCL":    1,
+	"ARG":    2,
+	"THIS":   3,
+	"THAT":   4,
+	"R0":     0,
+	"R1":     1,
+	"R2":     2,
+	"R3":     3,
+	"R4":     4,
+	"R5":     5,
+	"R6":     6,
+	"R7":     7,
+	"R8":     8,
+	"R9":     9,
+	"R10":    10,
+	"R11":    11,
+	"R12":    12,
+	"R13":    13,
+	"R14":    14,
+	"R15":    15,
+	"SCREEN": 16384,
+	"KBD":    24576,
+}
+
+// comp bits including the a bit, commutative operations are accepted in
+// both orders
+var compCodes = map[string]uint16{
+	"0":   0b0101010,
+	"1":   0b0111111,
+	"-1":  0b0111010,
+	"D":   0b0001100,
+	"A":   0b0110000,
+	"!D":  0b0001101,
+	"!A":  0b0110001,
+	"-D":  0b0001111,
+	"-A":  0b0110011,
+	"D+1": 0b0011111,
+	"A+1": 0b0110111,
+	"D-1": 0b0001110,
+	"A-1": 0b0110010,
+	"D+A": 0b0000010,
+	"A+D": 0b0000010,
+	"D-A": 0b0010011,
+	"A-D": 0b0000111,
+	"D&A": 0b0000000,
+	"A&D": 0b0000000,
+	"D|A": 0b0010101,
+	"A|D": 0b0010101,
+	"M":   0b1110000,
+	"!M":  0b1110001,
+	"-M":  0b1110011,
+	"M+1": 0b1110111,
+	"M-1": 0b1110010,
+	"D+M": 0b1000010,
+	"M+D": 0b1000010,
+	"D-M": 0b1010011,
+	"M-D": 0b1000111,
+	"D&M": 0b1000000,
+	"M&D": 0b1000000,
+	"D|M": 0b1010101,
+	"M|D": 0b1010101,
+}
+
+var destCodes = map[string]uint16{
+	"":    0b000,
+	"M":   0b001,
+	"D":   0b010,
+	"MD":  0b011,
+	"DM":  0b011,
+	"A":   0b100,
+	"AM":  0b101,
+	"MA":  0b101,
+	"AD":  0b110,
+	"DA":  0b110,
+	"AMD": 0b111,
+	"ADM": 0b111,
+}
+
+var jumpCodes = map[string]uint16{
+	"":    0b000,
+	"JGT": 0b001,
+	"JEQ": 0b010,
+	"JGE": 0b011,
+	"JLT": 0b100,
+	"JNE": 0b101,
+	"JLE": 0b110,
+	"JMP": 0b111,
+}
+
+// Error is an assembly error at a line of the source
+type Error struct {
+	Name string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Msg)
+}
+
+// instruction is a line of assembly without whitespace and comments
+type instruction struct {
+	text string
+	line int
+}
+
+// Assemble reads Hack assembly from r and returns the machine code, name is
+// used in error messages. Labels are resolved in a first pass, symbols that
+// are not labels become variables from RAM[16] on in the second pass.
+func Assemble(r io.Reader, name string) ([]uint16, error) {
+	code, _, err := AssembleVariables(r, name)
+	return code, err
+}
+
+// AssembleVariables assembles like Assemble and also returns the RAM
+// addresses it gave to the variables
+func AssembleVariables(r io.Reader, name string) ([]uint16, map[string]uint16, error) {
+	var instructions []instruction
+	symbols := map[string]uint16{}
+	for symbol, address := range predefinedSymbols {
+		symbols[symbol] = address
+	}
+
+	// first pass: collect instructions and label addresses
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := stripLine(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if text[0] == '(' {
+			if text[len(text)-1] != ')' || len(text) < 3 {
+				return nil, nil, &Error{name, lineNo, fmt.Sprintf("bad label %q", text)}
+			}
+			label := text[1 : len(text)-1]
+			if _, ok := symbols[label]; ok {
+				return nil, nil, &Error{name, lineNo, fmt.Sprintf("label %q is already defined", label)}
+			}
+			symbols[label] = uint16(len(instructions))
+			continue
+		}
+		instructions = append(instructions, instruction{text, lineNo})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// second pass: translate instructions, allocating variables
+	code := make([]uint16, 0, len(instructions))
+	nextVariable := uint16(variableBase)
+	variables := map[string]uint16{}
+	for _, inst := range instructions {
+		if inst.text[0] == '@' {
+			value := inst.text[1:]
+			if value == "" {
+				return nil, nil, &Error{name, inst.line, "missing value after @"}
+			}
+			if value[0] >= '0' && value[0] <= '9' {
+				n, err := strconv.Atoi(value)
+				if err != nil || n > maxAddress {
+					return nil, nil, &Error{name, inst.line, fmt.Sprintf("bad constant %q", value)}
+				}
+				code = append(code, uint16(n))
+				continue
+			}
+			address, ok := symbols[value]
+			if !ok {
+				address = nextVariable
+				symbols[value] = address
+				variables[value] = address
+				nextVariable++
+			}
+			code = append(code, address)
+			continue
+		}
+		word, err := cInstruction(inst.text)
+		if err != nil {
+			return nil, nil, &Error{name, inst.line, err.Error()}
+		}
+		code = append(code, word)
+	}
+	return code, variables, nil
+}
+
+// cInstruction encodes dest=comp;jump
+func cInstruction(text string) (uint16, error) {
+	dest, comp, jump := "", text, ""
+	if i := strings.IndexByte(comp, '='); i >= 0 {
+		dest, comp = comp[:i], comp[i+1:]
+	}
+	if i := strings.IndexByte(comp, ';'); i >= 0 {
+		comp, jump = comp[:i], comp[i+1:]
+	}
+	compCode, ok := compCodes[comp]
+	if !ok {
+		return 0, fmt.Errorf("unknown comp %q", comp)
+	}
+	destCode, ok := destCodes[dest]
+	if !ok {
+		return 0, fmt.Errorf("unknown dest %q", dest)
+	}
+	jumpCode, ok := jumpCodes[jump]
+	if !ok {
+		return 0, fmt.Errorf("unknown jump %q", jump)
+	}
+	return 0b111<<13 | compCode<<6 | destCode<<3 | jumpCode, nil
+}
+
+// stripLine removes comments and all whitespace from a line
+func stripLine(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+// WriteHack writes machine code as text, one instruction of 16 binary
+// digits per line, the format of .hack files
+func WriteHack(w io.Writer, code []uint16) error {
+	bw := bufio.NewWriter(w)
+	for _, word := range code {
+		if _, err := fmt.Fprintf(bw, "%016b\n", word); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,106 @@
+package assembler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssembleAdd(t *testing.T) {
+	file, err := os.Open("../../../06/add/Add.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	got, err := Assemble(file, "Add.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint16{
+		0b0000000000000010,
+		0b1110110000010000,
+		0b0000000000000011,
+		0b1110000010010000,
+		0b0000000000000000,
+		0b1110001100001000,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %016b, want %016b", got, want)
+	}
+}
+
+// TestAssembleGolden assembles the project 06 programs, with and without
+// symbols, and compares the code with testdata/<Name>.hack, which was
+// written by the Python assembler this package replaced
+func TestAssembleGolden(t *testing.T) {
+	for _, program := range []string{"add/Add", "max/Max", "max/MaxL", "rect/Rect", "rect/RectL", "pong/Pong", "pong/PongL"} {
+		t.Run(program, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := WriteHack(&got, assembleFile(t, "../../../06/"+program+".asm")); err != nil {
+				t.Fatal(err)
+			}
+			name := strings.TrimSuffix(filepath.Base(program), "L")
+			want, err := os.ReadFile(filepath.Join("testdata", name+".hack"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				gotLines, wantLines := strings.Split(got.String(), "\n"), strings.Split(string(want), "\n")
+				for i := range gotLines {
+					if i >= len(wantLines) || gotLines[i] != wantLines[i] {
+						t.Fatalf("line %d differs from %s.hack", i+1, name)
+					}
+				}
+				t.Fatalf("%d lines, %s.hack has %d", len(gotLines), name, len(wantLines))
+			}
+		})
+	}
+}
+
+func TestAssembleVariables(t *testing.T) {
+	src := "@i\nM=1\n@LOOP\n(LOOP)\n@sum\nM=0\n@i\n@R13\n@SCREEN\n@KBD\n"
+	code, variables, err := AssembleVariables(strings.NewReader(src), "Vars.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint16{16, 0b1110111111001000, 3, 17, 0b1110101010001000, 16, 13, 16384, 24576}
+	if !reflect.DeepEqual(code, want) {
+		t.Errorf("got %v, want %v", code, want)
+	}
+	if !reflect.DeepEqual(variables, map[string]uint16{"i": 16, "sum": 17}) {
+		t.Errorf("variables: got %v", variables)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"@32768\n", "Err.asm:1:"},
+		{"D=M\nD=X\n", "Err.asm:2:"},
+		{"(LOOP)\n(LOOP)\n", "Err.asm:2:"},
+	}
+	for _, test := range tests {
+		_, err := Assemble(strings.NewReader(test.src), "Err.asm")
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("Assemble(%q) error = %v, want %s...", test.src, err, test.want)
+		}
+	}
+}
+
+func assembleFile(t *testing.T, path string) []uint16 {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	code, err := Assemble(file, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
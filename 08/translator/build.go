@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"translator/jack"
+	"translator/vmtranslator"
+)
+
+// runBuild compiles the .jack files of a program directory and translates
+// them together with the other .vm files in it into <dir>/<dir>.asm, the
+// vm commands are passed on in memory
+func runBuild(args []string) {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	translateOptions := translateFlags(flags)
+	osDirs := osFlag(flags)
+	emit := flags.String("emit", "asm",
+		"output to write: vm (a .vm file per class, like the project 11 compiler), asm (Hack assembly) or hack (machine code)")
+	sourceMap := flags.Bool("source-map", false,
+		"also write <program>.map.json with the jack file, line, command and function of every ROM address")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatal("usage: translator build [--emit=vm|asm|hack] [-source-map] [-os dir] [translator flags] <dir>")
+	}
+	dir := flags.Arg(0)
+	if strings.HasSuffix(dir, ".jack") {
+		log.Fatalf("build takes the directory of a program, not a file: %s", dir)
+	}
+
+	jackFiles, err := jackPaths(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	modules, err := compileProgram(jackFiles)
+	if err != nil {
+		reportAndExit(err)
+	}
+	if *emit == "vm" {
+		if err := writeModules(dir, modules); err != nil {
+			log.Fatal(err)
+		}
+		for _, module := range modules {
+			fmt.Println(filepath.Join(dir, module.Name+".vm"))
+		}
+		return
+	}
+
+	vmFiles, asmPath, err := programPaths(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	others, err := parseProgram(otherVMFiles(vmFiles, modules))
+	if err != nil {
+		reportAndExit(err)
+	}
+	modules = append(modules, others...)
+	if len(*osDirs) > 0 {
+		var linked []vmtranslator.Module
+		modules, linked, err = linkOS(modules, *osDirs, translateOptions().Intrinsics)
+		if err != nil {
+			reportAndExit(err)
+		}
+		printLinked(linked)
+	}
+	if err := writeProgram(asmPath, modules, translateOptions(), *emit, *sourceMap); err != nil {
+		reportAndExit(err)
+	}
+}
+
+// formatModule writes the commands of module as a vm file
+func formatModule(module vmtranslator.Module) string {
+	var b strings.Builder
+	for _, c := range module.Commands {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeModules writes the modules as .vm files into dir
+func writeModules(dir string, modules []vmtranslator.Module) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, module := range modules {
+		path := filepath.Join(dir, module.Name+".vm")
+		if err := os.WriteFile(path, []byte(formatModule(module)), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileProgram compiles each jack file at paths into a module, the errors
+// of all files are reported together
+func compileProgram(paths []string) ([]vmtranslator.Module, error) {
+	var diagnostics vmtranslator.DiagnosticList
+	var modules []vmtranslator.Module
+	for _, path := range paths {
+		class, err := parseJackFile(path)
+		if err != nil {
+			diagnostics.Add(err)
+			continue
+		}
+		if name := strings.TrimSuffix(filepath.Base(path), ".jack"); class.Name != name {
+			diagnostics.Add(&vmtranslator.Diagnostic{Pos: class.Pos, Token: class.Name,
+				Msg: "class must be named after its file, " + name})
+		}
+		module, err := jack.Compile(class)
+		diagnostics.Add(err)
+		modules = append(modules, module)
+	}
+	return modules, diagnostics.Err()
+}
+
+// otherVMFiles returns the vm files that weren't compiled from the modules,
+// e.g. OS classes copied into the program; the .vm files of the modules are
+// left over from an earlier -emit vm
+func otherVMFiles(paths []string, modules []vmtranslator.Module) []string {
+	compiled := map[string]bool{}
+	for _, module := range modules {
+		compiled[module.Name] = true
+	}
+	var others []string
+	for _, path := range paths {
+		if !compiled[strings.TrimSuffix(filepath.Base(path), ".vm")] {
+			others = append(others, path)
+		}
+	}
+	return others
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"translator/vmtranslator"
+)
+
+// compilerPrograms are the project 11 programs, they have to compile
+// without errors and link with the OS classes of 12
+var compilerPrograms = []string{
+	"11/Seven",
+	"11/ConvertToBin",
+	"11/Square",
+	"11/Average",
+	"11/Pong",
+	"11/ComplexArrays",
+}
+
+// compileTestProgram compiles the jack files of the program dir
+func compileTestProgram(t *testing.T, dir string) []vmtranslator.Module {
+	paths, err := jackPaths(filepath.Join(root, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules, err := compileProgram(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return modules
+}
+
+// TestCompile compiles every compiler program, they must have no errors
+func TestCompile(t *testing.T) {
+	for _, program := range compilerPrograms {
+		t.Run(program, func(t *testing.T) {
+			compileTestProgram(t, program)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"translator/assembler"
+	"translator/emulator"
+	"translator/vmtranslator"
+)
+
+// comparisonBoundaries are the values TestComparisons compares with each
+// other with eq, gt and lt, every pair of them is checked. They include the
+// pairs where x-y overflows 16 bits, e.g. 32767 gt -32768.
+var comparisonBoundaries = []int16{
+	-32768, -32767, -16385, -16384, -2, -1, 0, 1, 2, 16383, 16384, 32766, 32767,
+}
+
+// comparisonResults is the RAM address the comparison programs write their
+// results to through the that segment
+const comparisonResults = 1000
+
+var comparisonOps = []string{"eq", "gt", "lt"}
+
+// TestComparisons runs eq, gt and lt on every pair of comparisonBoundaries
+// on the emulator in every regression mode and checks the results, with
+// FastCompare gt and lt are expected to follow the sign of the wrapped x-y.
+// One program per op keeps it within the ROM.
+func TestComparisons(t *testing.T) {
+	for _, mode := range regressionModes {
+		t.Run(mode.name, func(t *testing.T) {
+			for _, op := range comparisonOps {
+				t.Run(op, func(t *testing.T) {
+					testComparison(t, op, mode.opts)
+				})
+			}
+		})
+	}
+}
+
+func testComparison(t *testing.T, op string, opts vmtranslator.Options) {
+	var src strings.Builder
+	fmt.Fprintf(&src, "push constant %d\npop pointer 1\n", comparisonResults)
+	i := 0
+	for _, x := range comparisonBoundaries {
+		for _, y := range comparisonBoundaries {
+			src.WriteString(pushValue(x))
+			src.WriteString(pushValue(y))
+			fmt.Fprintf(&src, "%s\npop that %d\n", op, i)
+			i++
+		}
+	}
+
+	computer, err := runVMSource("Compare", src.String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i = 0
+	for _, x := range comparisonBoundaries {
+		for _, y := range comparisonBoundaries {
+			want := vmBool(compare(op, x, y, opts.FastCompare))
+			if got := computer.Peek(comparisonResults + i); got != want {
+				t.Errorf("%d %s %d: got %d, want %d", x, op, y, got, want)
+			}
+			i++
+		}
+	}
+}
+
+// runVMSource translates the vm commands src as the module name without
+// bootstrap code, runs them on the emulator with SP at 256 until they halt
+// and returns the emulator
+func runVMSource(name, src string, opts vmtranslator.Options) (*emulator.Computer, error) {
+	commands, err := vmtranslator.Parse(strings.NewReader(src), name+".vm")
+	if err != nil {
+		return nil, err
+	}
+	opts.Bootstrap = vmtranslator.BootstrapNever
+	return runModules([]vmtranslator.Module{{Name: name, Commands: commands}}, opts)
+}
+
+// runModules translates the modules with opts and runs them on the emulator
+// with SP at 256 until they halt
+func runModules(modules []vmtranslator.Module, opts vmtranslator.Options) (*emulator.Computer, error) {
+	var asm bytes.Buffer
+	if err := vmtranslator.Translate(modules, &asm, opts); err != nil {
+		return nil, err
+	}
+	code, err := assembler.Assemble(&asm, modules[0].Name+".asm")
+	if err != nil {
+		return nil, err
+	}
+	computer, err := emulator.New(code)
+	if err != nil {
+		return nil, err
+	}
+	computer.Poke(0, 256)
+	if err := computer.Run(10000000); err != nil {
+		return nil, err
+	}
+	return computer, nil
+}
+
+// compare is the result of the comparison op, fast compares the wrapped
+// x-y with 0 like the code written with FastCompare
+func compare(op string, x, y int16, fast bool) bool {
+	if fast {
+		x, y = x-y, 0
+	}
+	switch op {
+	case "eq":
+		return x == y
+	case "gt":
+		return x > y
+	}
+	return x < y
+}
+
+// pushValue returns the vm commands that push v, constants can't be negative
+func pushValue(v int16) string {
+	switch {
+	case v == -32768:
+		return "push constant 32767\nneg\npush constant 1\nsub\n"
+	case v < 0:
+		return fmt.Sprintf("push constant %d\nneg\n", -v)
+	}
+	return fmt.Sprintf("push constant %d\n", v)
+}
+
+// vmBool is true (-1) or false (0) as the vm represents them
+func vmBool(b bool) int16 {
+	if b {
+		return -1
+	}
+	return 0
+}
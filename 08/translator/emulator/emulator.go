@@ -0,0 +1,180 @@
+// Package emulator executes Hack machine code on an emulated Hack computer.
+package emulator
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ROMSize = 32768
+	// RAMSize covers the data memory, the screen and the keyboard register
+	RAMSize = 24577
+	Screen  = 16384
+	KBD     = 24576
+)
+
+// ErrCycleLimit is returned by Run when the program is still running after
+// the given number of cycles
+var ErrCycleLimit = errors.New("cycle limit reached")
+
+// Computer is the Hack computer: a CPU with A, D and PC registers, the
+// instruction memory ROM and the data memory RAM with the memory mapped
+// screen and keyboard
+type Computer struct {
+	ROM    []uint16
+	RAM    []uint16
+	A      uint16
+	D      uint16
+	PC     uint16
+	Cycles int
+	// size is the number of instructions of the loaded program
+	size int
+}
+
+// New returns a computer with program loaded into ROM and RAM cleared
+func New(program []uint16) (*Computer, error) {
+	if len(program) > ROMSize {
+		return nil, fmt.Errorf("program has %d instructions, ROM only holds %d", len(program), ROMSize)
+	}
+	rom := make([]uint16, ROMSize)
+	copy(rom, program)
+	return &Computer{ROM: rom, RAM: make([]uint16, RAMSize), size: len(program)}, nil
+}
+
+// Reset sets PC to 0 without touching the memory, like the reset input of
+// the CPU
+func (c *Computer) Reset() {
+	c.PC = 0
+}
+
+// Step executes the instruction at PC
+func (c *Computer) Step() error {
+	if int(c.PC) >= ROMSize {
+		return fmt.Errorf("PC %d is outside of ROM", c.PC)
+	}
+	inst := c.ROM[c.PC]
+	c.Cycles++
+
+	// A-instruction
+	if inst&0x8000 == 0 {
+		c.A = inst
+		c.PC++
+		return nil
+	}
+
+	// C-instruction: 111a cccc ccdd djjj
+	y := c.A
+	if inst&0x1000 != 0 {
+		if int(c.A) >= RAMSize {
+			return fmt.Errorf("PC %d: M reads RAM[%d] which is outside of RAM", c.PC, c.A)
+		}
+		y = c.RAM[c.A]
+	}
+	out := alu(c.D, y, inst>>6&0x3f)
+
+	if inst&0x08 != 0 {
+		if int(c.A) >= RAMSize {
+			return fmt.Errorf("PC %d: M writes RAM[%d] which is outside of RAM", c.PC, c.A)
+		}
+		if c.A == KBD {
+			return fmt.Errorf("PC %d: the keyboard register is read only", c.PC)
+		}
+		c.RAM[c.A] = out
+	}
+	// the jump uses A from before the instruction
+	target := c.A
+	if inst&0x20 != 0 {
+		c.A = out
+	}
+	if inst&0x10 != 0 {
+		c.D = out
+	}
+	if jumps(out, inst&0x7) {
+		c.PC = target
+	} else {
+		c.PC++
+	}
+	return nil
+}
+
+// alu computes the Hack ALU function selected by the control bits
+// zx nx zy ny f no
+func alu(x, y uint16, control uint16) uint16 {
+	if control&0x20 != 0 {
+		x = 0
+	}
+	if control&0x10 != 0 {
+		x = ^x
+	}
+	if control&0x08 != 0 {
+		y = 0
+	}
+	if control&0x04 != 0 {
+		y = ^y
+	}
+	var out uint16
+	if control&0x02 != 0 {
+		out = x + y
+	} else {
+		out = x & y
+	}
+	if control&0x01 != 0 {
+		out = ^out
+	}
+	return out
+}
+
+// jumps reports whether the jump bits j1 j2 j3 (< = >) match out
+func jumps(out uint16, jump uint16) bool {
+	v := int16(out)
+	return (jump&0x4 != 0 && v < 0) ||
+		(jump&0x2 != 0 && v == 0) ||
+		(jump&0x1 != 0 && v > 0)
+}
+
+// Halted reports whether the computer is stuck in the `(END) @END 0;JMP`
+// loop programs use to stop, or ran past the end of the program
+func (c *Computer) Halted() bool {
+	pc := int(c.PC)
+	if pc >= c.size {
+		return true
+	}
+	if pc+1 < ROMSize && int(c.ROM[pc]) == pc && isLoopJump(c.ROM[pc+1]) {
+		return true
+	}
+	return pc > 0 && isLoopJump(c.ROM[pc]) && int(c.A) == pc-1 && int(c.ROM[pc-1]) == pc-1
+}
+
+// isLoopJump reports whether inst is an unconditional jump that writes
+// nothing
+func isLoopJump(inst uint16) bool {
+	return inst&0xe000 == 0xe000 && inst&0x38 == 0 && inst&0x7 == 0x7
+}
+
+// Run executes instructions until the program halts or maxCycles
+// instructions were executed, in which case it returns ErrCycleLimit
+func (c *Computer) Run(maxCycles int) error {
+	for i := 0; i < maxCycles; i++ {
+		if c.Halted() {
+			return nil
+		}
+		if err := c.Step(); err != nil {
+			return err
+		}
+	}
+	if c.Halted() {
+		return nil
+	}
+	return ErrCycleLimit
+}
+
+// Peek returns RAM[address] as a signed value
+func (c *Computer) Peek(address int) int16 {
+	return int16(c.RAM[address])
+}
+
+// Poke sets RAM[address] to a signed value
+func (c *Computer) Poke(address int, value int16) {
+	c.RAM[address] = uint16(value)
+}
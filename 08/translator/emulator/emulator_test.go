@@ -0,0 +1,118 @@
+package emulator_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"translator/assembler"
+	"translator/emulator"
+	"translator/vmtranslator"
+)
+
+// programs are the project 08 programs with the RAM their test scripts set,
+// the number of cycles the scripts run and the RAM their compare files
+// expect
+var programs = []struct {
+	dir    string
+	set    map[int]int16
+	cycles int
+	want   map[int]int16
+}{
+	{
+		dir:    "ProgramFlow/BasicLoop",
+		set:    map[int]int16{0: 256, 1: 300, 2: 400, 400: 3},
+		cycles: 600,
+		want:   map[int]int16{0: 257, 256: 6},
+	},
+	{
+		dir:    "ProgramFlow/FibonacciSeries",
+		set:    map[int]int16{0: 256, 1: 300, 2: 400, 400: 6, 401: 3000},
+		cycles: 1100,
+		want:   map[int]int16{3000: 0, 3001: 1, 3002: 1, 3003: 2, 3004: 3, 3005: 5},
+	},
+	{
+		dir: "FunctionCalls/SimpleFunction",
+		set: map[int]int16{0: 317, 1: 317, 2: 310, 3: 3000, 4: 4000,
+			310: 1234, 311: 37, 312: 1000, 313: 305, 314: 300, 315: 3010, 316: 4010},
+		cycles: 300,
+		want:   map[int]int16{0: 311, 1: 305, 2: 300, 3: 3010, 4: 4010, 310: 1196},
+	},
+	{
+		dir:    "FunctionCalls/NestedCall",
+		set:    map[int]int16{0: 261, 1: 261, 2: 256, 3: -3, 4: -4, 5: -1, 6: -1},
+		cycles: 4000,
+		want:   map[int]int16{0: 261, 1: 261, 2: 256, 3: 4000, 4: 5000, 5: 135, 6: 246},
+	},
+	{
+		dir:    "FunctionCalls/FibonacciElement",
+		cycles: 6000,
+		want:   map[int]int16{0: 262, 261: 3},
+	},
+	{
+		dir:    "FunctionCalls/StaticsTest",
+		set:    map[int]int16{0: 256},
+		cycles: 2500,
+		want:   map[int]int16{0: 263, 261: -2, 262: 8},
+	},
+}
+
+// TestPrograms translates and assembles each program, runs it for the
+// cycles of its test script and checks RAM
+func TestPrograms(t *testing.T) {
+	for _, program := range programs {
+		t.Run(program.dir, func(t *testing.T) {
+			computer, err := emulator.New(build(t, filepath.Join("../..", program.dir)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for address, value := range program.set {
+				computer.Poke(address, value)
+			}
+			// the test scripts run a fixed number of cycles, programs that
+			// end in a loop of Sys.init are still running then
+			if err := computer.Run(program.cycles); err != nil && !errors.Is(err, emulator.ErrCycleLimit) {
+				t.Fatal(err)
+			}
+			for address, want := range program.want {
+				if got := computer.Peek(address); got != want {
+					t.Errorf("RAM[%d]: got %d, want %d", address, got, want)
+				}
+			}
+		})
+	}
+}
+
+// build translates the vm files of the program directory dir and assembles
+// them
+func build(t *testing.T, dir string) []uint16 {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.vm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var modules []vmtranslator.Module
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commands, err := vmtranslator.Parse(file, path)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Base(path)
+		modules = append(modules, vmtranslator.Module{Name: name[:len(name)-len(".vm")], Commands: commands})
+	}
+	var asm bytes.Buffer
+	if err := vmtranslator.Translate(modules, &asm, vmtranslator.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	code, err := assembler.Assemble(&asm, filepath.Base(dir)+".asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
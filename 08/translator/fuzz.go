@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+
+	"translator/assembler"
+	"translator/emulator"
+	"translator/vmemulator"
+	"translator/vmtranslator"
+)
+
+// runFuzz generates random vm programs, runs each on the vm interpreter and
+// translated on the emulator and stops at the first one where the RAM
+// differs, which is shrunk to a small reproducer
+func runFuzz(args []string) {
+	flags := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	translateOptions := translateFlags(flags)
+	n := flags.Int("n", 200, "number of programs to generate")
+	seed := flags.Int64("seed", 1, "seed of the first program, program i uses seed+i")
+	size := flags.Int("size", 12, "number of statements per function")
+	out := flags.String("out", "", "directory to write the shrunk reproducer's .vm files to")
+	flags.Parse(args)
+
+	opts := translateOptions()
+	for i := int64(0); i < int64(*n); i++ {
+		modules, err := generateProgram(rand.New(rand.NewSource(*seed+i)), *size)
+		if err != nil {
+			log.Fatalf("seed %d: generated program doesn't parse: %s", *seed+i, err)
+		}
+		d, err := diverge(modules, opts)
+		if err != nil {
+			log.Fatalf("seed %d: %s", *seed+i, err)
+		}
+		if d == nil {
+			continue
+		}
+
+		shrunk, d := shrinkProgram(modules, d, opts)
+		fmt.Printf("seed %d: %s\n", *seed+i, d)
+		fmt.Printf("shrunk from %d to %d commands:\n", countCommands(modules), countCommands(shrunk))
+		for _, module := range shrunk {
+			fmt.Printf("// %s.vm\n%s", module.Name, formatModule(module))
+		}
+		if *out != "" {
+			if err := writeModules(*out, shrunk); err != nil {
+				log.Fatal(err)
+			}
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("ok   %d programs\n", *n)
+}
+
+// fuzzThis and fuzzThat are the this and that areas Sys.init points to, so
+// the programs can use the segments without writing to random places
+const (
+	fuzzThis = 3000
+	fuzzThat = 3100
+	// fuzzIndexes is the number of cells used of the static, temp, this
+	// and that segments
+	fuzzIndexes = 8
+	// fuzzSteps limits the vm commands a generated program may run
+	fuzzSteps = 100000
+	// fuzzPrologue is the number of commands Sys.init starts with to set
+	// this and that
+	fuzzPrologue = 4
+)
+
+// fuzzFunction is a function of a generated program
+type fuzzFunction struct {
+	module  string
+	name    string
+	nArgs   int
+	nLocals int
+}
+
+type generator struct {
+	rng       *rand.Rand
+	src       map[string]*strings.Builder
+	functions []fuzzFunction
+	// function is the index of the function being generated, it may only
+	// call the ones after it so every program terminates
+	function int
+	labels   int
+	pending  []string
+}
+
+// generateProgram returns a random program of Sys.init and up to three
+// functions of Main it calls. Jumps only go forward and statements leave
+// the stack empty, so the program always halts in Sys.init's END loop.
+func generateProgram(rng *rand.Rand, size int) ([]vmtranslator.Module, error) {
+	g := &generator{rng: rng, src: map[string]*strings.Builder{"Sys": {}, "Main": {}}}
+	g.functions = append(g.functions, fuzzFunction{"Sys", "Sys.init", 0, rng.Intn(3)})
+	for i := rng.Intn(4); i > 0; i-- {
+		name := fmt.Sprintf("Main.f%d", len(g.functions))
+		g.functions = append(g.functions, fuzzFunction{"Main", name, rng.Intn(3), rng.Intn(3)})
+	}
+
+	for g.function = range g.functions {
+		f := g.functions[g.function]
+		g.emit("function %s %d", f.name, f.nLocals)
+		if g.function == 0 {
+			g.emit("push constant %d", fuzzThis)
+			g.emit("pop pointer 0")
+			g.emit("push constant %d", fuzzThat)
+			g.emit("pop pointer 1")
+		}
+		for i := 0; i < size; i++ {
+			g.statement()
+			if len(g.pending) > 0 && rng.Intn(3) == 0 {
+				g.placeLabel(rng.Intn(len(g.pending)))
+			}
+		}
+		for len(g.pending) > 0 {
+			g.placeLabel(0)
+		}
+		if g.function == 0 {
+			g.emit("label END")
+			g.emit("goto END")
+		} else {
+			g.expression(3)
+			g.emit("return")
+		}
+	}
+
+	var modules []vmtranslator.Module
+	for _, name := range []string{"Sys", "Main"} {
+		if g.src[name].Len() == 0 {
+			continue
+		}
+		commands, err := vmtranslator.Parse(strings.NewReader(g.src[name].String()), name+".vm")
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, vmtranslator.Module{Name: name, Commands: commands})
+	}
+	return modules, nil
+}
+
+func (g *generator) emit(format string, args ...interface{}) {
+	src := g.src[g.functions[g.function].module]
+	fmt.Fprintf(src, format, args...)
+	src.WriteByte('\n')
+}
+
+func (g *generator) placeLabel(i int) {
+	g.emit("label %s", g.pending[i])
+	g.pending = append(g.pending[:i], g.pending[i+1:]...)
+}
+
+// forwardLabel returns a label that is placed after the current statement
+func (g *generator) forwardLabel() string {
+	if len(g.pending) > 0 && g.rng.Intn(2) == 0 {
+		return g.pending[g.rng.Intn(len(g.pending))]
+	}
+	g.labels++
+	label := fmt.Sprintf("L%d", g.labels)
+	g.pending = append(g.pending, label)
+	return label
+}
+
+// statement writes commands that leave the stack as they found it
+func (g *generator) statement() {
+	switch r := g.rng.Intn(20); {
+	case r < 10:
+		g.expression(3)
+		g.pop()
+	case r < 14:
+		g.expression(2)
+		g.emit("if-goto %s", g.forwardLabel())
+	case r < 15:
+		g.emit("goto %s", g.forwardLabel())
+	default:
+		if g.function == len(g.functions)-1 {
+			g.expression(3)
+			g.pop()
+			return
+		}
+		callee := g.functions[g.function+1+g.rng.Intn(len(g.functions)-g.function-1)]
+		for i := 0; i < callee.nArgs; i++ {
+			g.expression(2)
+		}
+		g.emit("call %s %d", callee.name, callee.nArgs)
+		g.pop()
+	}
+}
+
+// fuzzConstants are the constants that are picked more often than others
+var fuzzConstants = []int{0, 1, 2, 16384, 32766, 32767}
+
+// expression pushes one value computed by a tree of at most depth levels
+func (g *generator) expression(depth int) {
+	if depth == 0 || g.rng.Intn(3) == 0 {
+		g.push()
+		return
+	}
+	switch r := g.rng.Intn(9); {
+	case r < 2:
+		g.expression(depth - 1)
+		g.emit([]string{"neg", "not"}[r])
+	default:
+		g.expression(depth - 1)
+		g.expression(depth - 1)
+		g.emit([]string{"add", "sub", "and", "or", "eq", "gt", "lt"}[r-2])
+	}
+}
+
+// segment returns a segment and index the current function may access
+func (g *generator) segment() (string, int) {
+	f := g.functions[g.function]
+	for {
+		switch g.rng.Intn(6) {
+		case 0:
+			if f.nLocals > 0 {
+				return "local", g.rng.Intn(f.nLocals)
+			}
+		case 1:
+			if f.nArgs > 0 {
+				return "argument", g.rng.Intn(f.nArgs)
+			}
+		case 2:
+			return "static", g.rng.Intn(fuzzIndexes)
+		case 3:
+			return "temp", g.rng.Intn(fuzzIndexes)
+		case 4:
+			return "this", g.rng.Intn(fuzzIndexes)
+		case 5:
+			return "that", g.rng.Intn(fuzzIndexes)
+		}
+	}
+}
+
+func (g *generator) push() {
+	switch r := g.rng.Intn(4); {
+	case r == 0:
+		g.emit("push constant %d", fuzzConstants[g.rng.Intn(len(fuzzConstants))])
+	case r == 1:
+		g.emit("push constant %d", g.rng.Intn(32768))
+	default:
+		segment, index := g.segment()
+		g.emit("push %s %d", segment, index)
+	}
+}
+
+func (g *generator) pop() {
+	segment, index := g.segment()
+	g.emit("pop %s %d", segment, index)
+}
+
+// divergence is the first RAM cell where the translated program ended up
+// different from the interpreter
+type divergence struct {
+	address int
+	// static is the variable the cell holds, e.g. Main.3
+	static      string
+	interpreter int16
+	translated  int16
+	// err is set instead if the translated program failed to run
+	err error
+}
+
+func (d *divergence) String() string {
+	if d.err != nil {
+		return fmt.Sprintf("the translated program fails: %s", d.err)
+	}
+	if d.static != "" {
+		return fmt.Sprintf("static %s differs: interpreter %d, translated %d", d.static, d.interpreter, d.translated)
+	}
+	return fmt.Sprintf("RAM[%d] differs: interpreter %d, translated %d", d.address, d.interpreter, d.translated)
+}
+
+// diverge runs modules on the interpreter and translated with opts on the
+// emulator and compares the RAM cells the program state is in. It returns
+// an error if the program doesn't run on the interpreter.
+func diverge(modules []vmtranslator.Module, opts vmtranslator.Options) (*divergence, error) {
+	vm, err := vmemulator.New(modules)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Bootstrap("Sys.init"); err != nil {
+		return nil, err
+	}
+	if err := vm.Run(fuzzSteps); err != nil {
+		return nil, err
+	}
+
+	var asm bytes.Buffer
+	opts.Bootstrap = vmtranslator.BootstrapAuto
+	if err := vmtranslator.Translate(modules, &asm, opts); err != nil {
+		return &divergence{err: err}, nil
+	}
+	code, variables, err := assembler.AssembleVariables(&asm, "Fuzz.asm")
+	if err != nil {
+		return &divergence{err: err}, nil
+	}
+	computer, err := emulator.New(code)
+	if err != nil {
+		return &divergence{err: err}, nil
+	}
+	if err := computer.Run(100 * fuzzSteps); err != nil {
+		return &divergence{err: err}, nil
+	}
+
+	// the statics are compared by name, the optimizers may leave some out
+	// of the translated program so the others get different addresses. A
+	// static that was left out keeps its initial 0.
+	for _, module := range modules {
+		for i := 0; i < fuzzIndexes; i++ {
+			address, ok := vm.StaticAddress(module.Name, i)
+			if !ok {
+				continue
+			}
+			var translated int16
+			if a, ok := variables[fmt.Sprintf("static.%s.%d", module.Name, i)]; ok {
+				translated = computer.Peek(int(a))
+			}
+			if vm.Peek(address) != translated {
+				return &divergence{static: fmt.Sprintf("%s.%d", module.Name, i),
+					interpreter: vm.Peek(address), translated: translated}, nil
+			}
+		}
+	}
+
+	// the pointers and temp, the this and that areas and the locals and
+	// stack of Sys.init. R13-R15 are scratch registers of the translated
+	// code and the rest of the stack holds return addresses, which are
+	// command indexes in the interpreter.
+	var cells []int
+	for a := vmemulator.SP; a < vmemulator.Temp+fuzzIndexes; a++ {
+		cells = append(cells, a)
+	}
+	for a := 0; a < fuzzIndexes; a++ {
+		cells = append(cells, fuzzThis+a, fuzzThat+a)
+	}
+	for a := int(vm.Peek(vmemulator.LCL)); a < int(vm.Peek(vmemulator.SP)); a++ {
+		cells = append(cells, a)
+	}
+	for _, a := range cells {
+		if a < 0 || a >= emulator.RAMSize {
+			continue
+		}
+		if vm.Peek(a) != computer.Peek(a) {
+			return &divergence{address: a, interpreter: vm.Peek(a), translated: computer.Peek(a)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// shrinkProgram removes commands from a diverging program as long as it
+// stays well formed and still diverges, first in large chunks and then one
+// at a time, and then the functions nothing calls anymore. It returns the
+// smallest program found and its divergence.
+func shrinkProgram(modules []vmtranslator.Module, d *divergence, opts vmtranslator.Options) ([]vmtranslator.Module, *divergence) {
+	for chunk := countCommands(modules) / 2; chunk >= 1; {
+		removed := false
+		for m := range modules {
+			for start := 0; start < len(modules[m].Commands); {
+				candidate, ok := removeCommands(modules, m, start, chunk)
+				if !ok {
+					start++
+					continue
+				}
+				if !wellFormed(candidate) {
+					start++
+					continue
+				}
+				cd, err := diverge(candidate, opts)
+				if err != nil || cd == nil {
+					start++
+					continue
+				}
+				modules, d = candidate, cd
+				removed = true
+			}
+		}
+		if !removed {
+			chunk /= 2
+		}
+	}
+
+	for removed := true; removed; {
+		removed = false
+		for _, name := range uncalledFunctions(modules) {
+			candidate := removeFunction(modules, name)
+			if !wellFormed(candidate) {
+				continue
+			}
+			cd, err := diverge(candidate, opts)
+			if err != nil || cd == nil {
+				continue
+			}
+			modules, d = candidate, cd
+			removed = true
+		}
+	}
+	return modules, d
+}
+
+// uncalledFunctions returns the functions but Sys.init that no call of the
+// program names
+func uncalledFunctions(modules []vmtranslator.Module) []string {
+	called := map[string]bool{"Sys.init": true}
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type == vmtranslator.C_CALL {
+				called[c.Arg1] = true
+			}
+		}
+	}
+	var uncalled []string
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type == vmtranslator.C_FUNCTION && !called[c.Arg1] {
+				uncalled = append(uncalled, c.Arg1)
+			}
+		}
+	}
+	return uncalled
+}
+
+// removeFunction returns the modules without function name, from its
+// function command up to the next one, and without the modules that are
+// left empty
+func removeFunction(modules []vmtranslator.Module, name string) []vmtranslator.Module {
+	var candidate []vmtranslator.Module
+	for _, module := range modules {
+		var kept []vmtranslator.Command
+		removing := false
+		for _, c := range module.Commands {
+			if c.Type == vmtranslator.C_FUNCTION {
+				removing = c.Arg1 == name
+			}
+			if !removing {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) > 0 {
+			candidate = append(candidate, vmtranslator.Module{Name: module.Name, Commands: kept})
+		}
+	}
+	return candidate
+}
+
+// removeCommands returns the modules without n commands of module m from
+// start on, function commands, Sys.init's prologue and its END loop are
+// kept
+func removeCommands(modules []vmtranslator.Module, m, start, n int) ([]vmtranslator.Module, bool) {
+	commands := modules[m].Commands
+	if start+n > len(commands) {
+		return nil, false
+	}
+	for i := start; i < start+n; i++ {
+		if c := commands[i]; c.Type == vmtranslator.C_FUNCTION || c.Arg1 == "END" || inPrologue(commands, i) {
+			return nil, false
+		}
+	}
+	candidate := append([]vmtranslator.Module(nil), modules...)
+	kept := append([]vmtranslator.Command(nil), commands[:start]...)
+	candidate[m].Commands = append(kept, commands[start+n:]...)
+	return candidate, true
+}
+
+// inPrologue reports whether commands[i] is one of the fuzzPrologue
+// commands after function Sys.init that point this and that to their
+// areas, without them the program writes through THIS=0 and THAT=0 into
+// SP and LCL
+func inPrologue(commands []vmtranslator.Command, i int) bool {
+	for k := 1; k <= fuzzPrologue && k <= i; k++ {
+		if c := commands[i-k]; c.Type == vmtranslator.C_FUNCTION {
+			return c.Arg1 == "Sys.init"
+		}
+	}
+	return false
+}
+
+// wellFormed checks what the generator guarantees: the program is valid,
+// commands never pop more than their function pushed, the stack is empty
+// at jumps and labels and every function but Sys.init ends with return
+func wellFormed(modules []vmtranslator.Module) bool {
+	// Validate also checks that jumps stay in their function
+	if vmtranslator.Validate(modules) != nil {
+		return false
+	}
+	for _, module := range modules {
+		depth := 0
+		function := ""
+		for i, c := range module.Commands {
+			switch c.Type {
+			case vmtranslator.C_FUNCTION:
+				if function != "" && function != "Sys.init" && module.Commands[i-1].Type != vmtranslator.C_RETURN {
+					return false
+				}
+				function = c.Arg1
+				depth = 0
+			case vmtranslator.C_PUSH:
+				depth++
+			case vmtranslator.C_POP, vmtranslator.C_IF:
+				depth--
+			case vmtranslator.C_ARITHMETIC:
+				if c.Arg1 != "neg" && c.Arg1 != "not" {
+					depth--
+				}
+				if depth < 1 {
+					return false
+				}
+			case vmtranslator.C_CALL:
+				if depth < c.Arg2 {
+					return false
+				}
+				depth += 1 - c.Arg2
+			case vmtranslator.C_RETURN:
+				if depth < 1 {
+					return false
+				}
+				depth = 0
+			}
+			if depth < 0 {
+				return false
+			}
+			switch c.Type {
+			case vmtranslator.C_LABEL, vmtranslator.C_GOTO, vmtranslator.C_IF:
+				if depth != 0 {
+					return false
+				}
+			}
+		}
+		if function != "Sys.init" && module.Commands[len(module.Commands)-1].Type != vmtranslator.C_RETURN {
+			return false
+		}
+	}
+	return true
+}
+
+func countCommands(modules []vmtranslator.Module) int {
+	n := 0
+	for _, module := range modules {
+		n += len(module.Commands)
+	}
+	return n
+}
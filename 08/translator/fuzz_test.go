@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"translator/vmtranslator"
+)
+
+// TestFuzz runs the programs of a few fixed seeds on the interpreter and
+// translated in every regression mode. These seeds have no comparison
+// where x-y overflows, so they pass with FastCompare too.
+func TestFuzz(t *testing.T) {
+	for _, mode := range regressionModes {
+		t.Run(mode.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 10; seed++ {
+				modules, err := generateProgram(rand.New(rand.NewSource(seed)), 12)
+				if err != nil {
+					t.Fatalf("seed %d: %s", seed, err)
+				}
+				d, err := diverge(modules, mode.opts)
+				if err != nil {
+					t.Fatalf("seed %d: %s", seed, err)
+				}
+				if d != nil {
+					t.Errorf("seed %d: %s", seed, d)
+				}
+			}
+		})
+	}
+}
+
+// TestFuzzShrink shrinks a program whose overflowing comparison gives a
+// different result with FastCompare
+func TestFuzzShrink(t *testing.T) {
+	opts := vmtranslator.Options{FastCompare: true}
+	modules, err := generateProgram(rand.New(rand.NewSource(12)), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := diverge(modules, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil {
+		t.Fatal("no divergence with FastCompare")
+	}
+
+	shrunk, d := shrinkProgram(modules, d, opts)
+	if n, m := countCommands(shrunk), countCommands(modules); n >= m {
+		t.Errorf("shrunk to %d of %d commands", n, m)
+	}
+	if !wellFormed(shrunk) {
+		t.Error("shrunk program is not well formed")
+	}
+	if uncalled := uncalledFunctions(shrunk); len(uncalled) > 0 {
+		t.Errorf("shrunk program has uncalled functions %q", uncalled)
+	}
+	if cd, err := diverge(shrunk, opts); err != nil || cd == nil || cd.String() != d.String() {
+		t.Errorf("shrunk program diverges with %v, %v, want %s", cd, err, d)
+	}
+	if cd, err := diverge(shrunk, vmtranslator.Options{}); err != nil || cd != nil {
+		t.Errorf("shrunk program diverges without FastCompare: %v, %v", cd, err)
+	}
+}
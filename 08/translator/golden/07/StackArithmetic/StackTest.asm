@@ -0,0 +1,599 @@
+// push constant 17
+@17
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 17
+@17
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// eq
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+D=M-D
+@CMD2
+D;JEQ
+@SP
+A=M
+M=0
+@END2
+0;JMP
+(CMD2)
+@SP
+A=M
+M=-1
+(END2)
+@SP
+M=M+1
+// push constant 17
+@17
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 16
+@16
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// eq
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+D=M-D
+@CMD5
+D;JEQ
+@SP
+A=M
+M=0
+@END5
+0;JMP
+(CMD5)
+@SP
+A=M
+M=-1
+(END5)
+@SP
+M=M+1
+// push constant 16
+@16
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 17
+@17
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// eq
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+D=M-D
+@CMD8
+D;JEQ
+@SP
+A=M
+M=0
+@END8
+0;JMP
+(CMD8)
+@SP
+A=M
+M=-1
+(END8)
+@SP
+M=M+1
+// push constant 892
+@892
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 891
+@891
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// lt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG11
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB11
+D;JGE
+D=-1
+@TEST11
+0;JMP
+(YNEG11)
+@SP
+M=M-1
+A=M
+D=M
+@SUB11
+D;JLT
+D=1
+@TEST11
+0;JMP
+(SUB11)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST11)
+@CMD11
+D;JLT
+@SP
+A=M
+M=0
+@END11
+0;JMP
+(CMD11)
+@SP
+A=M
+M=-1
+(END11)
+@SP
+M=M+1
+// push constant 891
+@891
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 892
+@892
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// lt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG14
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB14
+D;JGE
+D=-1
+@TEST14
+0;JMP
+(YNEG14)
+@SP
+M=M-1
+A=M
+D=M
+@SUB14
+D;JLT
+D=1
+@TEST14
+0;JMP
+(SUB14)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST14)
+@CMD14
+D;JLT
+@SP
+A=M
+M=0
+@END14
+0;JMP
+(CMD14)
+@SP
+A=M
+M=-1
+(END14)
+@SP
+M=M+1
+// push constant 891
+@891
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 891
+@891
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// lt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG17
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB17
+D;JGE
+D=-1
+@TEST17
+0;JMP
+(YNEG17)
+@SP
+M=M-1
+A=M
+D=M
+@SUB17
+D;JLT
+D=1
+@TEST17
+0;JMP
+(SUB17)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST17)
+@CMD17
+D;JLT
+@SP
+A=M
+M=0
+@END17
+0;JMP
+(CMD17)
+@SP
+A=M
+M=-1
+(END17)
+@SP
+M=M+1
+// push constant 32767
+@32767
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 32766
+@32766
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// gt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG20
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB20
+D;JGE
+D=-1
+@TEST20
+0;JMP
+(YNEG20)
+@SP
+M=M-1
+A=M
+D=M
+@SUB20
+D;JLT
+D=1
+@TEST20
+0;JMP
+(SUB20)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST20)
+@CMD20
+D;JGT
+@SP
+A=M
+M=0
+@END20
+0;JMP
+(CMD20)
+@SP
+A=M
+M=-1
+(END20)
+@SP
+M=M+1
+// push constant 32766
+@32766
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 32767
+@32767
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// gt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG23
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB23
+D;JGE
+D=-1
+@TEST23
+0;JMP
+(YNEG23)
+@SP
+M=M-1
+A=M
+D=M
+@SUB23
+D;JLT
+D=1
+@TEST23
+0;JMP
+(SUB23)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST23)
+@CMD23
+D;JGT
+@SP
+A=M
+M=0
+@END23
+0;JMP
+(CMD23)
+@SP
+A=M
+M=-1
+(END23)
+@SP
+M=M+1
+// push constant 32766
+@32766
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 32766
+@32766
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// gt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG26
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB26
+D;JGE
+D=-1
+@TEST26
+0;JMP
+(YNEG26)
+@SP
+M=M-1
+A=M
+D=M
+@SUB26
+D;JLT
+D=1
+@TEST26
+0;JMP
+(SUB26)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST26)
+@CMD26
+D;JGT
+@SP
+A=M
+M=0
+@END26
+0;JMP
+(CMD26)
+@SP
+A=M
+M=-1
+(END26)
+@SP
+M=M+1
+// push constant 57
+@57
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 31
+@31
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 53
+@53
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// add
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M+D
+@SP
+M=M+1
+
+// push constant 112
+@112
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// neg
+@SP
+M=M-1
+A=M
+M=-M
+@SP
+M=M+1
+
+// and
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M&D
+@SP
+M=M+1
+
+// push constant 82
+@82
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// or
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M|D
+@SP
+M=M+1
+
+// not
+@SP
+M=M-1
+A=M
+M=!M
+@SP
+M=M+1
+
@@ -0,0 +1,545 @@
+// ** start init
+@256
+D=A
+@SP
+M=D
+// ** start call Sys.init 0 **
+// push return-address
+@Sys.init:0:2
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@0
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Sys.init
+@Sys.init
+0;JMP
+// label return-address
+(Sys.init:0:2)
+// ** end call Sys.init 0 **
+// ** end init
+// function Main.fibonacci 0
+(Main.fibonacci)
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 2
+@2
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// lt
+@SP
+M=M-1
+A=M
+D=M
+@YNEG28
+D;JLT
+@SP
+M=M-1
+A=M
+D=M
+@SUB28
+D;JGE
+D=-1
+@TEST28
+0;JMP
+(YNEG28)
+@SP
+M=M-1
+A=M
+D=M
+@SUB28
+D;JLT
+D=1
+@TEST28
+0;JMP
+(SUB28)
+@SP
+A=M+1
+D=M
+A=A-1
+D=M-D
+(TEST28)
+@CMD28
+D;JLT
+@SP
+A=M
+M=0
+@END28
+0;JMP
+(CMD28)
+@SP
+A=M
+M=-1
+(END28)
+@SP
+M=M+1
+// if-goto IF_TRUE
+@SP
+M=M-1
+A=M
+D=M
+@Main.fibonacci$IF_TRUE
+D;JNE
+// goto IF_FALSE
+@Main.fibonacci$IF_FALSE
+0;JMP
+(Main.fibonacci$IF_TRUE)
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+(Main.fibonacci$IF_FALSE)
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 2
+@2
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// ** start call Main.fibonacci 1 **
+// push return-address
+@Main.fibonacci:1:56
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@1
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Main.fibonacci
+@Main.fibonacci
+0;JMP
+// label return-address
+(Main.fibonacci:1:56)
+// ** end call Main.fibonacci 1 **
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 1
+@1
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// ** start call Main.fibonacci 1 **
+// push return-address
+@Main.fibonacci:1:80
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@1
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Main.fibonacci
+@Main.fibonacci
+0;JMP
+// label return-address
+(Main.fibonacci:1:80)
+// ** end call Main.fibonacci 1 **
+// add
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M+D
+@SP
+M=M+1
+
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+// function Sys.init 0
+(Sys.init)
+// push constant 4
+@4
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start call Main.fibonacci 1 **
+// push return-address
+@Main.fibonacci:1:122
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@1
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Main.fibonacci
+@Main.fibonacci
+0;JMP
+// label return-address
+(Main.fibonacci:1:122)
+// ** end call Main.fibonacci 1 **
+(Sys.init$WHILE)
+// goto WHILE
+@Sys.init$WHILE
+0;JMP
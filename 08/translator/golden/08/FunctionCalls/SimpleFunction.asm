@@ -0,0 +1,162 @@
+// function SimpleFunction.test 2
+(SimpleFunction.test)
+// push constant 0
+@0
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 0
+@0
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push local 0
+@LCL
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push local 1
+@LCL
+D=M
+@1
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// add
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M+D
+@SP
+M=M+1
+
+// not
+@SP
+M=M-1
+A=M
+M=!M
+@SP
+M=M+1
+
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// add
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M+D
+@SP
+M=M+1
+
+// push argument 1
+@ARG
+D=M
+@1
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
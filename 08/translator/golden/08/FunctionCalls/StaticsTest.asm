@@ -0,0 +1,758 @@
+// ** start init
+@256
+D=A
+@SP
+M=D
+// ** start call Sys.init 0 **
+// push return-address
+@Sys.init:0:2
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@0
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Sys.init
+@Sys.init
+0;JMP
+// label return-address
+(Sys.init:0:2)
+// ** end call Sys.init 0 **
+// ** end init
+// function Class1.set 0
+(Class1.set)
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// pop static 0
+@SP
+M=M-1
+A=M
+D=M
+@static.Class1.0
+M=D
+// push argument 1
+@ARG
+D=M
+@1
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// pop static 1
+@SP
+M=M-1
+A=M
+D=M
+@static.Class1.1
+M=D
+// push constant 0
+@0
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+// function Class1.get 0
+(Class1.get)
+// push static 0
+@static.Class1.0
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push static 1
+@static.Class1.1
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+// function Class2.set 0
+(Class2.set)
+// push argument 0
+@ARG
+D=M
+@0
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// pop static 0
+@SP
+M=M-1
+A=M
+D=M
+@static.Class2.0
+M=D
+// push argument 1
+@ARG
+D=M
+@1
+A=A+D
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// pop static 1
+@SP
+M=M-1
+A=M
+D=M
+@static.Class2.1
+M=D
+// push constant 0
+@0
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+// function Class2.get 0
+(Class2.get)
+// push static 0
+@static.Class2.0
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push static 1
+@static.Class2.1
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// sub
+@SP
+M=M-1
+A=M
+D=M
+@SP
+M=M-1
+A=M
+M=M-D
+@SP
+M=M+1
+
+// ** start return **
+// FRAME = LCL
+@LCL
+D=M
+@R13
+M=D
+// RET = *(FRAME - 5)
+@5
+A=D-A
+D=M
+@R14
+M=D
+// *ARG = pop()
+@SP
+M=M-1
+A=M
+D=M
+@ARG
+A=M
+M=D
+// SP = ARG + 1
+@ARG
+A=M
+D=A+1
+@SP
+M=D
+// THAT THIS ARG LCL
+@R13
+M=M-1
+A=M
+D=M
+@THAT
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@THIS
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@ARG
+M=D
+@R13
+M=M-1
+A=M
+D=M
+@LCL
+M=D
+// goto RET
+@R14
+A=M
+0;JMP
+// ** end return **
+// function Sys.init 0
+(Sys.init)
+// push constant 6
+@6
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 8
+@8
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start call Class1.set 2 **
+// push return-address
+@Class1.set:2:120
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@2
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Class1.set
+@Class1.set
+0;JMP
+// label return-address
+(Class1.set:2:120)
+// ** end call Class1.set 2 **
+// pop temp 0
+@R5
+D=A
+@0
+D=A+D
+@R13
+M=D
+@SP
+M=M-1
+A=M
+D=M
+@R13
+A=M
+M=D
+// push constant 23
+@23
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push constant 15
+@15
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ** start call Class2.set 2 **
+// push return-address
+@Class2.set:2:144
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@2
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Class2.set
+@Class2.set
+0;JMP
+// label return-address
+(Class2.set:2:144)
+// ** end call Class2.set 2 **
+// pop temp 0
+@R5
+D=A
+@0
+D=A+D
+@R13
+M=D
+@SP
+M=M-1
+A=M
+D=M
+@R13
+A=M
+M=D
+// ** start call Class1.get 0 **
+// push return-address
+@Class1.get:0:166
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@0
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Class1.get
+@Class1.get
+0;JMP
+// label return-address
+(Class1.get:0:166)
+// ** end call Class1.get 0 **
+// ** start call Class2.get 0 **
+// push return-address
+@Class2.get:0:187
+D=A
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push LCL
+@LCL
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push ARG
+@ARG
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THIS
+@THIS
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// push THAT
+@THAT
+D=M
+@SP
+A=M
+M=D
+@SP
+M=M+1
+// ARG = SP - n - 5
+@SP
+D=M
+@5
+D=D-A
+@0
+D=D-A
+@ARG
+M=D
+// LCL = SP
+@SP
+D=M
+@LCL
+M=D
+// goto f
+// goto Class2.get
+@Class2.get
+0;JMP
+// label return-address
+(Class2.get:0:187)
+// ** end call Class2.get 0 **
+(Sys.init$WHILE)
+// goto WHILE
+@Sys.init$WHILE
+0;JMP
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"translator/emulator"
+	"translator/vmtranslator"
+)
+
+// intrinsicValues are the values TestIntrinsics multiplies and divides with
+// each other, the comparison boundaries and operands of MathTest
+var intrinsicValues = append([]int16{3, -7, 30, 100, 181, -18000}, comparisonBoundaries...)
+
+// intrinsicMemory is where the memory intrinsics are checked
+const intrinsicMemory = 3000
+
+// intrinsicTests are the project 12 test programs TestIntrinsics runs. The
+// OS classes of 12 are still stubs, so the calls of other OS functions halt
+// the program and only the columns of the expected output that are
+// computed before the first such call are compared.
+var intrinsicTests = []struct {
+	dir     string
+	columns int
+}{
+	{"12/MathTest", 8},
+	{"12/MemoryTest", 2},
+}
+
+// TestIntrinsics runs Math.multiply and Math.divide on every pair of
+// intrinsicValues and Memory.poke and Memory.peek on each of them with
+// Intrinsics on the emulator in every regression mode and checks the
+// results against the Jack OS, then runs the intrinsicTests
+func TestIntrinsics(t *testing.T) {
+	for _, mode := range regressionModes {
+		t.Run(mode.name, func(t *testing.T) {
+			opts := mode.opts
+			opts.Intrinsics = true
+			for _, function := range []string{"Math.multiply", "Math.divide"} {
+				t.Run(function, func(t *testing.T) {
+					testArithmeticIntrinsic(t, function, opts)
+				})
+			}
+			t.Run("Memory", func(t *testing.T) {
+				testMemoryIntrinsics(t, opts)
+			})
+			for _, test := range intrinsicTests {
+				t.Run(test.dir, func(t *testing.T) {
+					testIntrinsicProgram(t, filepath.Join(root, test.dir), test.columns, opts)
+				})
+			}
+		})
+	}
+}
+
+// jackOS returns the result of the Jack OS function for x and y, the
+// product keeps its low 16 bits and the quotient is truncated towards 0
+func jackOS(function string, x, y int16) int16 {
+	if function == "Math.multiply" {
+		return x * y
+	}
+	return x / y
+}
+
+// testArithmeticIntrinsic translates a program that calls function with
+// every pair of intrinsicValues, except divisions by 0
+func testArithmeticIntrinsic(t *testing.T, function string, opts vmtranslator.Options) {
+	var src strings.Builder
+	fmt.Fprintf(&src, "push constant %d\npop pointer 1\n", comparisonResults)
+	i := 0
+	for _, x := range intrinsicValues {
+		for _, y := range intrinsicValues {
+			if y == 0 && function == "Math.divide" {
+				continue
+			}
+			src.WriteString(pushValue(x))
+			src.WriteString(pushValue(y))
+			fmt.Fprintf(&src, "call %s 2\npop that %d\n", function, i)
+			i++
+		}
+	}
+
+	computer, err := runVMSource("Intrinsics", src.String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i = 0
+	for _, x := range intrinsicValues {
+		for _, y := range intrinsicValues {
+			if y == 0 && function == "Math.divide" {
+				continue
+			}
+			want := jackOS(function, x, y)
+			if got := computer.Peek(comparisonResults + i); got != want {
+				t.Errorf("%s(%d, %d): got %d, want %d", function, x, y, got, want)
+			}
+			i++
+		}
+	}
+}
+
+// testMemoryIntrinsics pokes each of intrinsicValues into memory and peeks
+// it back, poke has to return 0
+func testMemoryIntrinsics(t *testing.T, opts vmtranslator.Options) {
+	var src strings.Builder
+	fmt.Fprintf(&src, "push constant %d\npop pointer 1\n", comparisonResults)
+	for i, v := range intrinsicValues {
+		fmt.Fprintf(&src, "push constant %d\n", intrinsicMemory+i)
+		src.WriteString(pushValue(v))
+		fmt.Fprintf(&src, "call Memory.poke 2\npop that %d\n", 2*i)
+		fmt.Fprintf(&src, "push constant %d\ncall Memory.peek 1\npop that %d\n", intrinsicMemory+i, 2*i+1)
+	}
+
+	computer, err := runVMSource("Intrinsics", src.String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range intrinsicValues {
+		if got := computer.Peek(intrinsicMemory + i); got != v {
+			t.Errorf("Memory.poke(%d, %d): RAM[%d] is %d", intrinsicMemory+i, v, intrinsicMemory+i, got)
+		}
+		if got := computer.Peek(comparisonResults + 2*i); got != 0 {
+			t.Errorf("Memory.poke(%d, %d): got %d, want 0", intrinsicMemory+i, v, got)
+		}
+		if got := computer.Peek(comparisonResults + 2*i + 1); got != v {
+			t.Errorf("Memory.peek(%d): got %d, want %d", intrinsicMemory+i, got, v)
+		}
+	}
+}
+
+// testIntrinsicProgram compiles the test program in dir, runs it with the
+// other OS functions halting it and compares the first columns of its
+// expected output with RAM
+func testIntrinsicProgram(t *testing.T, dir string, columns int, opts vmtranslator.Options) {
+	paths, err := jackPaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules, err := compileProgram(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standIns, err := haltingStandIns(modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	computer, err := runModules(append(modules, standIns...), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses, values, err := readCompareFile(filepath.Join(dir, filepath.Base(dir)+".cmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns > len(values) {
+		t.Fatalf("expected output has %d columns, not %d", len(values), columns)
+	}
+	for i := 0; i < columns; i++ {
+		if got := computer.Peek(addresses[i]); got != values[i] {
+			t.Errorf("RAM[%d]: got %d, want %d", addresses[i], got, values[i])
+		}
+	}
+}
+
+// haltingStandIns returns modules that define the functions the modules call
+// but don't define, other than intrinsics, as endless loops, and a Sys.init
+// that calls Main.main
+func haltingStandIns(modules []vmtranslator.Module) ([]vmtranslator.Module, error) {
+	defined := map[string]bool{}
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type == vmtranslator.C_FUNCTION {
+				defined[c.Arg1] = true
+			}
+		}
+	}
+	classes := map[string]*strings.Builder{}
+	source := func(function string) *strings.Builder {
+		class, _, _ := strings.Cut(function, ".")
+		if classes[class] == nil {
+			classes[class] = &strings.Builder{}
+		}
+		return classes[class]
+	}
+	if !defined["Sys.init"] {
+		defined["Sys.init"] = true
+		fmt.Fprint(source("Sys.init"), "function Sys.init 0\ncall Main.main 0\nlabel END\ngoto END\n")
+	}
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type != vmtranslator.C_CALL || defined[c.Arg1] || vmtranslator.IsIntrinsic(c.Arg1, c.Arg2) {
+				continue
+			}
+			defined[c.Arg1] = true
+			fmt.Fprintf(source(c.Arg1), "function %s 0\nlabel HALT\ngoto HALT\n", c.Arg1)
+		}
+	}
+
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Strings(names)
+	var standIns []vmtranslator.Module
+	for _, class := range names {
+		commands, err := vmtranslator.Parse(strings.NewReader(classes[class].String()), class+".vm")
+		if err != nil {
+			return nil, err
+		}
+		standIns = append(standIns, vmtranslator.Module{Name: class, Commands: commands})
+	}
+	return standIns, nil
+}
+
+// readCompareFile returns the RAM addresses of the header of a .cmp file
+// with one row of output and the values of the row
+func readCompareFile(path string) ([]int, []int16, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	var rows [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			rows = append(rows, strings.Split(strings.Trim(line, "|"), "|"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(rows) != 2 || len(rows[0]) != len(rows[1]) {
+		return nil, nil, fmt.Errorf("%s: expected a header and one row of output", path)
+	}
+	addresses := make([]int, len(rows[0]))
+	values := make([]int16, len(rows[1]))
+	for i := range rows[0] {
+		var address int
+		if _, err := fmt.Sscanf(strings.TrimSpace(rows[0][i]), "RAM[%d]", &address); err != nil || address >= emulator.RAMSize {
+			return nil, nil, fmt.Errorf("%s: column %q is not a RAM address", path, rows[0][i])
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(rows[1][i]), 10, 16)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		addresses[i], values[i] = address, int16(v)
+	}
+	return addresses, values, nil
+}
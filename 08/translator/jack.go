@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"translator/jack"
+	"translator/vmtranslator"
+)
+
+// runTokenize writes the tokens of jack files as <Name>T.xml, like the
+// tokenizer stage of the project 10 analyzer
+func runTokenize(args []string) {
+	runAnalyzer("tokenize", "T.xml", tokenizeFile, args)
+}
+
+// runParse writes the parse trees of jack files as <Name>.xml, like the
+// project 10 analyzer
+func runParse(args []string) {
+	runAnalyzer("parse", ".xml", parseTreeFile, args)
+}
+
+// runAnalyzer writes the xml produced for each jack file of the command
+// line as <Name><suffix>
+func runAnalyzer(command, suffix string, produce func(path string) ([]byte, error), args []string) {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	out := flags.String("out", "", "directory to write the .xml files to, default the out directory next to the .jack files")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("usage: translator %s [-out dir] <file.jack|dir>", command)
+	}
+
+	paths, err := jackPaths(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var diagnostics vmtranslator.DiagnosticList
+	for _, path := range paths {
+		xml, err := produce(path)
+		if err != nil {
+			diagnostics.Add(err)
+			continue
+		}
+		xmlPath := xmlOutputPath(path, *out, suffix)
+		if err := os.MkdirAll(filepath.Dir(xmlPath), 0755); err != nil {
+			diagnostics.Add(err)
+			continue
+		}
+		diagnostics.Add(os.WriteFile(xmlPath, xml, 0644))
+		fmt.Println(xmlPath)
+	}
+	if len(diagnostics) > 0 {
+		reportAndExit(diagnostics)
+	}
+}
+
+// jackPaths returns the .jack file path or the .jack files in the directory
+// path, sorted by name
+func jackPaths(path string) ([]string, error) {
+	if strings.HasSuffix(path, ".jack") {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jack") {
+			paths = append(paths, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .jack files in %s", path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// xmlOutputPath returns the path of the xml file for the jack file path,
+// <Name><suffix> in dir or in the out directory next to the jack file, the
+// project 10 programs keep their expected xml files next to the jack files
+func xmlOutputPath(path, dir, suffix string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".jack") + suffix
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(path), "out")
+	}
+	return filepath.Join(dir, name)
+}
+
+// tokenizeFile returns the token XML of the jack file at path
+func tokenizeFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tokens, err := jack.Tokenize(file, path)
+	if err != nil {
+		return nil, err
+	}
+	var xml bytes.Buffer
+	if err := jack.WriteTokens(&xml, tokens); err != nil {
+		return nil, err
+	}
+	return xml.Bytes(), nil
+}
+
+// parseTreeFile returns the parse tree XML of the jack file at path
+func parseTreeFile(path string) ([]byte, error) {
+	class, err := parseJackFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var xml bytes.Buffer
+	if err := jack.WriteClass(&xml, class); err != nil {
+		return nil, err
+	}
+	return xml.Bytes(), nil
+}
+
+// parseJackFile parses the jack file at path
+func parseJackFile(path string) (*jack.Class, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return jack.Parse(file, path)
+}
@@ -0,0 +1,173 @@
+package jack
+
+import "translator/vmtranslator"
+
+// Class is a parsed jack file, a file holds exactly one class
+type Class struct {
+	Name        string
+	Vars        []*ClassVarDec
+	Subroutines []*Subroutine
+	Pos         vmtranslator.Position
+}
+
+// ClassVarDec declares the static or field variables Names of Type
+type ClassVarDec struct {
+	// Kind is "static" or "field"
+	Kind  string
+	Type  string
+	Names []string
+	Pos   vmtranslator.Position
+}
+
+// Subroutine is a constructor, function or method of a class
+type Subroutine struct {
+	// Kind is "constructor", "function" or "method"
+	Kind       string
+	ReturnType string
+	Name       string
+	Params     []*Param
+	Vars       []*VarDec
+	Statements []Statement
+	Pos        vmtranslator.Position
+}
+
+// Param is a parameter of a subroutine
+type Param struct {
+	Type string
+	Name string
+	Pos  vmtranslator.Position
+}
+
+// VarDec declares the local variables Names of Type
+type VarDec struct {
+	Type  string
+	Names []string
+	Pos   vmtranslator.Position
+}
+
+// Statement is one of *LetStatement, *IfStatement, *WhileStatement,
+// *DoStatement and *ReturnStatement
+type Statement interface {
+	statement()
+}
+
+// LetStatement assigns Value to Name, or to Name[Index] when Index isn't nil
+type LetStatement struct {
+	Name  string
+	Index *Expression
+	Value *Expression
+	Pos   vmtranslator.Position
+}
+
+// IfStatement runs Then if Cond is true and Else otherwise, HasElse tells
+// an empty else block from a missing one
+type IfStatement struct {
+	Cond    *Expression
+	Then    []Statement
+	Else    []Statement
+	HasElse bool
+	Pos     vmtranslator.Position
+}
+
+// WhileStatement runs Body as long as Cond is true
+type WhileStatement struct {
+	Cond *Expression
+	Body []Statement
+	Pos  vmtranslator.Position
+}
+
+// DoStatement calls a subroutine and drops its return value
+type DoStatement struct {
+	Call *SubroutineCall
+	Pos  vmtranslator.Position
+}
+
+// ReturnStatement returns Value, or nothing from a void subroutine when
+// Value is nil
+type ReturnStatement struct {
+	Value *Expression
+	Pos   vmtranslator.Position
+}
+
+func (*LetStatement) statement()    {}
+func (*IfStatement) statement()     {}
+func (*WhileStatement) statement()  {}
+func (*DoStatement) statement()     {}
+func (*ReturnStatement) statement() {}
+
+// Expression is a term followed by binary operations. The language has no
+// operator precedence, the operations apply from left to right.
+type Expression struct {
+	Term Term
+	Ops  []BinaryOp
+	Pos  vmtranslator.Position
+}
+
+// BinaryOp applies Op to the value so far and Term, Op is one of +-*/&|<>=
+type BinaryOp struct {
+	Op   string
+	Term Term
+	Pos  vmtranslator.Position
+}
+
+// Term is one of *IntegerConstant, *StringConstant, *KeywordConstant,
+// *VarTerm, *SubroutineCall, *ParenTerm and *UnaryTerm
+type Term interface {
+	term()
+}
+
+// IntegerConstant is an integer from 0 to 32767
+type IntegerConstant struct {
+	Value int
+	Pos   vmtranslator.Position
+}
+
+// StringConstant is a string without its quotes
+type StringConstant struct {
+	Value string
+	Pos   vmtranslator.Position
+}
+
+// KeywordConstant is true, false, null or this
+type KeywordConstant struct {
+	Keyword string
+	Pos     vmtranslator.Position
+}
+
+// VarTerm is the variable Name, or the array element Name[Index] when Index
+// isn't nil
+type VarTerm struct {
+	Name  string
+	Index *Expression
+	Pos   vmtranslator.Position
+}
+
+// SubroutineCall calls Name, of Receiver when it isn't empty. Receiver is
+// a class or variable name, which one is only known with a symbol table.
+type SubroutineCall struct {
+	Receiver string
+	Name     string
+	Args     []*Expression
+	Pos      vmtranslator.Position
+}
+
+// ParenTerm is an expression in parentheses
+type ParenTerm struct {
+	Expr *Expression
+	Pos  vmtranslator.Position
+}
+
+// UnaryTerm applies Op, "-" or "~", to Term
+type UnaryTerm struct {
+	Op   string
+	Term Term
+	Pos  vmtranslator.Position
+}
+
+func (*IntegerConstant) term() {}
+func (*StringConstant) term()  {}
+func (*KeywordConstant) term() {}
+func (*VarTerm) term()         {}
+func (*SubroutineCall) term()  {}
+func (*ParenTerm) term()       {}
+func (*UnaryTerm) term()       {}
@@ -0,0 +1,323 @@
+package jack
+
+import (
+	"fmt"
+
+	"translator/vmtranslator"
+)
+
+// binaryCommands are the vm commands of the binary operators, * and / are
+// calls to the OS
+var binaryCommands = map[string]string{
+	"+": "add",
+	"-": "sub",
+	"&": "and",
+	"|": "or",
+	"<": "lt",
+	">": "gt",
+	"=": "eq",
+}
+
+// primitiveTypes have no methods
+var primitiveTypes = map[string]bool{"int": true, "char": true, "boolean": true}
+
+// Compile translates class into the vm commands of a module named after
+// it, like the project 11 compiler. Calls to other classes are not checked,
+// vmtranslator.Validate reports the ones no module defines. All errors
+// found are returned together as a vmtranslator.DiagnosticList.
+func Compile(class *Class) (vmtranslator.Module, error) {
+	c := &compiler{
+		class:       class,
+		symbols:     newSymbolTable(),
+		subroutines: map[string]*Subroutine{},
+	}
+	for _, d := range class.Vars {
+		for _, name := range d.Names {
+			c.diagnostics.Add(c.symbols.define(name, d.Type, varKind(d.Kind), d.Pos))
+		}
+	}
+	for _, s := range class.Subroutines {
+		if prev, ok := c.subroutines[s.Name]; ok {
+			c.errorf(s.Pos, s.Name, "subroutine is already defined at %s", prev.Pos)
+			continue
+		}
+		c.subroutines[s.Name] = s
+	}
+	for _, s := range class.Subroutines {
+		c.subroutine(s)
+	}
+	return vmtranslator.Module{Name: class.Name, Commands: c.commands}, c.diagnostics.Err()
+}
+
+type compiler struct {
+	class       *Class
+	symbols     *symbolTable
+	subroutines map[string]*Subroutine
+	// current is the subroutine being compiled
+	current *Subroutine
+	// labels numbers the if and while statements of the subroutine
+	labels      int
+	commands    []vmtranslator.Command
+	diagnostics vmtranslator.DiagnosticList
+}
+
+func (c *compiler) errorf(pos vmtranslator.Position, token string, format string, args ...interface{}) {
+	c.diagnostics.Add(&vmtranslator.Diagnostic{Pos: pos, Token: token, Msg: fmt.Sprintf(format, args...)})
+}
+
+// emit appends a command, the position of every command is that of the
+// jack code it was compiled from
+func (c *compiler) emit(pos vmtranslator.Position, typ vmtranslator.CommandType, arg1 string, arg2 int) {
+	cmd := vmtranslator.Command{Type: typ, Arg1: arg1, Arg2: arg2, Pos: pos, Arg1Pos: pos, Arg2Pos: pos}
+	cmd.Line = cmd.String()
+	c.commands = append(c.commands, cmd)
+}
+
+func (c *compiler) push(pos vmtranslator.Position, segment string, index int) {
+	c.emit(pos, vmtranslator.C_PUSH, segment, index)
+}
+
+func (c *compiler) pop(pos vmtranslator.Position, segment string, index int) {
+	c.emit(pos, vmtranslator.C_POP, segment, index)
+}
+
+func (c *compiler) arithmetic(pos vmtranslator.Position, op string) {
+	c.emit(pos, vmtranslator.C_ARITHMETIC, op, -1)
+}
+
+func (c *compiler) call(pos vmtranslator.Position, function string, nArgs int) {
+	c.emit(pos, vmtranslator.C_CALL, function, nArgs)
+}
+
+// newLabels returns labels for the next if or while statement, e.g.
+// IF_FALSE0 and IF_END0
+func (c *compiler) newLabels(first, second string) (string, string) {
+	n := c.labels
+	c.labels++
+	return fmt.Sprintf("%s%d", first, n), fmt.Sprintf("%s%d", second, n)
+}
+
+func (c *compiler) subroutine(s *Subroutine) {
+	c.current = s
+	c.labels = 0
+	c.symbols.startSubroutine()
+	if s.Kind == "method" {
+		c.symbols.define("this", c.class.Name, argumentVar, s.Pos)
+	}
+	for _, param := range s.Params {
+		c.diagnostics.Add(c.symbols.define(param.Name, param.Type, argumentVar, param.Pos))
+	}
+	for _, d := range s.Vars {
+		for _, name := range d.Names {
+			c.diagnostics.Add(c.symbols.define(name, d.Type, localVar, d.Pos))
+		}
+	}
+
+	c.emit(s.Pos, vmtranslator.C_FUNCTION, c.class.Name+"."+s.Name, c.symbols.count(localVar))
+	switch s.Kind {
+	case "constructor":
+		c.push(s.Pos, "constant", c.symbols.count(fieldVar))
+		c.call(s.Pos, "Memory.alloc", 1)
+		c.pop(s.Pos, "pointer", 0)
+	case "method":
+		c.push(s.Pos, "argument", 0)
+		c.pop(s.Pos, "pointer", 0)
+	}
+	c.statements(s.Statements)
+}
+
+// lookup returns the variable name used at pos, it reports variables that
+// are not defined and fields used in functions
+func (c *compiler) lookup(name string, pos vmtranslator.Position) (variable, bool) {
+	v, ok := c.symbols.lookup(name)
+	if !ok {
+		c.errorf(pos, name, "variable is not defined")
+		return v, false
+	}
+	if v.Kind == fieldVar && c.current.Kind == "function" {
+		c.errorf(pos, name, "field can't be used in a function")
+		return v, false
+	}
+	return v, true
+}
+
+func (c *compiler) pushVariable(v variable, pos vmtranslator.Position) {
+	c.push(pos, segments[v.Kind], v.Index)
+}
+
+func (c *compiler) statements(statements []Statement) {
+	for _, s := range statements {
+		c.statement(s)
+	}
+}
+
+func (c *compiler) statement(s Statement) {
+	switch s := s.(type) {
+	case *LetStatement:
+		v, ok := c.lookup(s.Name, s.Pos)
+		if s.Index != nil {
+			// the address goes to the stack first because computing the
+			// value can change pointer 1
+			if ok {
+				c.pushVariable(v, s.Pos)
+			}
+			c.expression(s.Index)
+			c.arithmetic(s.Pos, "add")
+			c.expression(s.Value)
+			c.pop(s.Pos, "temp", 0)
+			c.pop(s.Pos, "pointer", 1)
+			c.push(s.Pos, "temp", 0)
+			c.pop(s.Pos, "that", 0)
+			return
+		}
+		c.expression(s.Value)
+		if ok {
+			c.pop(s.Pos, segments[v.Kind], v.Index)
+		}
+	case *IfStatement:
+		falseLabel, endLabel := c.newLabels("IF_FALSE", "IF_END")
+		c.expression(s.Cond)
+		c.arithmetic(s.Pos, "not")
+		c.emit(s.Pos, vmtranslator.C_IF, falseLabel, -1)
+		c.statements(s.Then)
+		if s.HasElse {
+			c.emit(s.Pos, vmtranslator.C_GOTO, endLabel, -1)
+		}
+		c.emit(s.Pos, vmtranslator.C_LABEL, falseLabel, -1)
+		if s.HasElse {
+			c.statements(s.Else)
+			c.emit(s.Pos, vmtranslator.C_LABEL, endLabel, -1)
+		}
+	case *WhileStatement:
+		expLabel, endLabel := c.newLabels("WHILE_EXP", "WHILE_END")
+		c.emit(s.Pos, vmtranslator.C_LABEL, expLabel, -1)
+		c.expression(s.Cond)
+		c.arithmetic(s.Pos, "not")
+		c.emit(s.Pos, vmtranslator.C_IF, endLabel, -1)
+		c.statements(s.Body)
+		c.emit(s.Pos, vmtranslator.C_GOTO, expLabel, -1)
+		c.emit(s.Pos, vmtranslator.C_LABEL, endLabel, -1)
+	case *DoStatement:
+		c.subroutineCall(s.Call)
+		c.pop(s.Pos, "temp", 0)
+	case *ReturnStatement:
+		void := c.current.ReturnType == "void"
+		switch {
+		case s.Value == nil && !void:
+			c.errorf(s.Pos, "return", "%s must return a value", c.current.Name)
+		case s.Value != nil && void:
+			c.errorf(s.Pos, "return", "void %s can't return a value", c.current.Name)
+		}
+		if s.Value != nil {
+			c.expression(s.Value)
+		} else {
+			c.push(s.Pos, "constant", 0)
+		}
+		c.emit(s.Pos, vmtranslator.C_RETURN, "", -1)
+	}
+}
+
+// expression applies the operators from left to right, the language has
+// no precedence
+func (c *compiler) expression(e *Expression) {
+	c.term(e.Term)
+	for _, op := range e.Ops {
+		c.term(op.Term)
+		switch op.Op {
+		case "*":
+			c.call(op.Pos, "Math.multiply", 2)
+		case "/":
+			c.call(op.Pos, "Math.divide", 2)
+		default:
+			c.arithmetic(op.Pos, binaryCommands[op.Op])
+		}
+	}
+}
+
+func (c *compiler) term(t Term) {
+	switch t := t.(type) {
+	case *IntegerConstant:
+		c.push(t.Pos, "constant", t.Value)
+	case *StringConstant:
+		c.push(t.Pos, "constant", len(t.Value))
+		c.call(t.Pos, "String.new", 1)
+		for i := 0; i < len(t.Value); i++ {
+			c.push(t.Pos, "constant", int(t.Value[i]))
+			c.call(t.Pos, "String.appendChar", 2)
+		}
+	case *KeywordConstant:
+		switch t.Keyword {
+		case "true":
+			c.push(t.Pos, "constant", 0)
+			c.arithmetic(t.Pos, "not")
+		case "false", "null":
+			c.push(t.Pos, "constant", 0)
+		case "this":
+			if c.current.Kind == "function" {
+				c.errorf(t.Pos, "this", "this can't be used in a function")
+			}
+			c.push(t.Pos, "pointer", 0)
+		}
+	case *VarTerm:
+		v, ok := c.lookup(t.Name, t.Pos)
+		if ok {
+			c.pushVariable(v, t.Pos)
+		}
+		if t.Index != nil {
+			c.expression(t.Index)
+			c.arithmetic(t.Pos, "add")
+			c.pop(t.Pos, "pointer", 1)
+			c.push(t.Pos, "that", 0)
+		}
+	case *SubroutineCall:
+		c.subroutineCall(t)
+	case *ParenTerm:
+		c.expression(t.Expr)
+	case *UnaryTerm:
+		c.term(t.Term)
+		if t.Op == "-" {
+			c.arithmetic(t.Pos, "neg")
+		} else {
+			c.arithmetic(t.Pos, "not")
+		}
+	}
+}
+
+// subroutineCall pushes the object for method calls and the arguments and
+// calls the subroutine. Without a receiver it is a subroutine of the class,
+// a receiver that is a variable makes it a method of the variable's class.
+func (c *compiler) subroutineCall(call *SubroutineCall) {
+	nArgs := len(call.Args)
+	var function string
+	if v, ok := c.symbols.lookup(call.Receiver); ok {
+		if primitiveTypes[v.Type] {
+			c.errorf(call.Pos, call.Receiver, "%s has no methods", v.Type)
+		}
+		if v, ok := c.lookup(call.Receiver, call.Pos); ok {
+			c.pushVariable(v, call.Pos)
+		}
+		nArgs++
+		function = v.Type + "." + call.Name
+	} else if call.Receiver == "" || call.Receiver == c.class.Name {
+		s, ok := c.subroutines[call.Name]
+		switch {
+		case !ok:
+			c.errorf(call.Pos, call.Name, "subroutine is not defined in class %s", c.class.Name)
+		case s.Kind == "method" && call.Receiver != "":
+			c.errorf(call.Pos, call.Name, "method must be called on an object")
+		case s.Kind == "method":
+			if c.current.Kind == "function" {
+				c.errorf(call.Pos, call.Name, "method can't be called from a function without an object")
+			}
+			c.push(call.Pos, "pointer", 0)
+			nArgs++
+		}
+		function = c.class.Name + "." + call.Name
+	} else {
+		function = call.Receiver + "." + call.Name
+	}
+	for _, arg := range call.Args {
+		c.expression(arg)
+	}
+	c.call(call.Pos, function, nArgs)
+}
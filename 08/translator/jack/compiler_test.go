@@ -0,0 +1,287 @@
+package jack
+
+import (
+	"strings"
+	"testing"
+)
+
+// compileSource compiles the class in src and returns its commands as a vm
+// file
+func compileSource(t *testing.T, src string) string {
+	t.Helper()
+	class, err := Parse(strings.NewReader(src), "Main.jack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	module, err := Compile(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, c := range module.Commands {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestCompileClasses(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "segments and indexes",
+			src: `class Main {
+  static int a, b;
+  field int x, y;
+  method void set(int p, int q) {
+    var int i, j;
+    let y = q;
+    let j = p;
+    let b = i;
+    let x = a;
+    return;
+  }
+  function int f(int p) {
+    var boolean k;
+    let k = p;
+    return b;
+  }
+}
+`,
+			want: `function Main.set 2
+push argument 0
+pop pointer 0
+push argument 2
+pop this 1
+push argument 1
+pop local 1
+push local 0
+pop static 1
+push static 0
+pop this 0
+push constant 0
+return
+function Main.f 1
+push argument 0
+pop local 0
+push static 1
+return
+`,
+		},
+		{
+			name: "constructor and methods",
+			src: `class Main {
+  field int x, y, z;
+  constructor Main new(int ax) {
+    let x = ax;
+    do draw();
+    return this;
+  }
+  method void draw() {
+    var Main other;
+    do other.draw();
+    do Output.printInt(x);
+    return;
+  }
+}
+`,
+			want: `function Main.new 0
+push constant 3
+call Memory.alloc 1
+pop pointer 0
+push argument 0
+pop this 0
+push pointer 0
+call Main.draw 1
+pop temp 0
+push pointer 0
+return
+function Main.draw 1
+push argument 0
+pop pointer 0
+push local 0
+call Main.draw 1
+pop temp 0
+push this 0
+call Output.printInt 1
+pop temp 0
+push constant 0
+return
+`,
+		},
+		{
+			name: "arrays",
+			src: `class Main {
+  function void main() {
+    var Array a, b;
+    var int i;
+    let a[i] = b[i + 1];
+    let a[a[0]] = 2 * 3;
+    return;
+  }
+}
+`,
+			want: `function Main.main 3
+push local 0
+push local 2
+add
+push local 1
+push local 2
+push constant 1
+add
+add
+pop pointer 1
+push that 0
+pop temp 0
+pop pointer 1
+push temp 0
+pop that 0
+push local 0
+push local 0
+push constant 0
+add
+pop pointer 1
+push that 0
+add
+push constant 2
+push constant 3
+call Math.multiply 2
+pop temp 0
+pop pointer 1
+push temp 0
+pop that 0
+push constant 0
+return
+`,
+		},
+		{
+			name: "string constants and keywords",
+			src: `class Main {
+  function void main() {
+    do Output.printString("Hi!");
+    do Output.printString("");
+    do Main.f(true, false, null, -1, ~0);
+    return;
+  }
+  function void f(boolean a, boolean b, Array c, int d, int e) {
+    return;
+  }
+}
+`,
+			want: `function Main.main 0
+push constant 3
+call String.new 1
+push constant 72
+call String.appendChar 2
+push constant 105
+call String.appendChar 2
+push constant 33
+call String.appendChar 2
+call Output.printString 1
+pop temp 0
+push constant 0
+call String.new 1
+call Output.printString 1
+pop temp 0
+push constant 0
+not
+push constant 0
+push constant 0
+push constant 1
+neg
+push constant 0
+not
+call Main.f 5
+pop temp 0
+push constant 0
+return
+function Main.f 0
+push constant 0
+return
+`,
+		},
+		{
+			name: "unique labels",
+			src: `class Main {
+  function void main() {
+    var int i;
+    while (i < 3) {
+      if (i = 1) {
+        let i = 2;
+      } else {
+        if (i = 2) {
+          let i = 3;
+        }
+      }
+    }
+    if (i) {
+      let i = 0;
+    }
+    return;
+  }
+  function void g() {
+    while (true) {
+    }
+    return;
+  }
+}
+`,
+			want: `function Main.main 1
+label WHILE_EXP0
+push local 0
+push constant 3
+lt
+not
+if-goto WHILE_END0
+push local 0
+push constant 1
+eq
+not
+if-goto IF_FALSE1
+push constant 2
+pop local 0
+goto IF_END1
+label IF_FALSE1
+push local 0
+push constant 2
+eq
+not
+if-goto IF_FALSE2
+push constant 3
+pop local 0
+label IF_FALSE2
+label IF_END1
+goto WHILE_EXP0
+label WHILE_END0
+push local 0
+not
+if-goto IF_FALSE3
+push constant 0
+pop local 0
+label IF_FALSE3
+push constant 0
+return
+function Main.g 0
+label WHILE_EXP0
+push constant 0
+not
+not
+if-goto WHILE_END0
+goto WHILE_EXP0
+label WHILE_END0
+push constant 0
+return
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := compileSource(t, test.src); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package jack
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"translator/vmtranslator"
+)
+
+// Parse parses the jack source read from r into its class, name is used in
+// the positions. Tokenizer errors are returned as a
+// vmtranslator.DiagnosticList, a syntax error stops the parse and is
+// returned as a *vmtranslator.Diagnostic naming the token that was expected.
+func Parse(r io.Reader, name string) (*Class, error) {
+	tokens, err := Tokenize(r, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(tokens, name)
+}
+
+// ParseTokens parses the tokens of the jack file name into its class
+func ParseTokens(tokens []Token, name string) (class *Class, err error) {
+	p := &parser{tokens: tokens, name: name}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			class, err = nil, e.Diagnostic
+		}
+	}()
+	class = p.class()
+	if p.i < len(p.tokens) {
+		p.fail("end of file")
+	}
+	return class, nil
+}
+
+// syntaxError is the panic value of parser.fail, recovered by ParseTokens
+type syntaxError struct {
+	*vmtranslator.Diagnostic
+}
+
+type parser struct {
+	tokens []Token
+	name   string
+	// i is the index of the next token
+	i int
+}
+
+// peek returns the next token, or nil at the end of the file
+func (p *parser) peek() *Token {
+	if p.i >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.i]
+}
+
+// is reports whether the next token is of kind and one of texts, any text
+// matches if there are none
+func (p *parser) is(kind TokenKind, texts ...string) bool {
+	t := p.peek()
+	if t == nil || t.Kind != kind {
+		return false
+	}
+	if len(texts) == 0 {
+		return true
+	}
+	for _, text := range texts {
+		if t.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// isType reports whether the next token can start a type
+func (p *parser) isType() bool {
+	return p.is(Keyword, "int", "char", "boolean") || p.is(Identifier)
+}
+
+func (p *parser) next() Token {
+	t := p.tokens[p.i]
+	p.i++
+	return t
+}
+
+// fail stops the parse with an error at the next token
+func (p *parser) fail(expected string) {
+	t := p.peek()
+	if t == nil {
+		pos := vmtranslator.Position{File: p.name}
+		if len(p.tokens) > 0 {
+			pos = p.tokens[len(p.tokens)-1].Pos
+		}
+		panic(syntaxError{&vmtranslator.Diagnostic{Pos: pos, Msg: fmt.Sprintf("expected %s at end of file", expected)}})
+	}
+	panic(syntaxError{&vmtranslator.Diagnostic{Pos: t.Pos, Token: t.String(), Msg: "expected " + expected}})
+}
+
+// expect returns the next token if it is of kind and one of texts, see is
+func (p *parser) expect(kind TokenKind, texts ...string) Token {
+	if !p.is(kind, texts...) {
+		p.fail(describe(kind, texts))
+	}
+	return p.next()
+}
+
+// describe names the tokens of kind and one of texts for errors, e.g.
+// "'static' or 'field'"
+func describe(kind TokenKind, texts []string) string {
+	if len(texts) == 0 {
+		return string(kind)
+	}
+	quoted := make([]string, len(texts))
+	for i, text := range texts {
+		quoted[i] = "'" + text + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+func (p *parser) symbol(s string) Token {
+	return p.expect(Symbol, s)
+}
+
+func (p *parser) identifier() string {
+	return p.expect(Identifier).Text
+}
+
+func (p *parser) typeName() string {
+	if !p.isType() {
+		p.fail("type")
+	}
+	return p.next().Text
+}
+
+// class: 'class' className '{' classVarDec* subroutineDec* '}'
+func (p *parser) class() *Class {
+	c := &Class{Pos: p.expect(Keyword, "class").Pos}
+	c.Name = p.identifier()
+	p.symbol("{")
+	for p.is(Keyword, "static", "field") {
+		c.Vars = append(c.Vars, p.classVarDec())
+	}
+	for p.is(Keyword, "constructor", "function", "method") {
+		c.Subroutines = append(c.Subroutines, p.subroutineDec())
+	}
+	if !p.is(Symbol, "}") {
+		p.fail("'static', 'field', 'constructor', 'function', 'method' or '}'")
+	}
+	p.next()
+	return c
+}
+
+// classVarDec: ('static' | 'field') type varName (',' varName)* ';'
+func (p *parser) classVarDec() *ClassVarDec {
+	kind := p.next()
+	d := &ClassVarDec{Kind: kind.Text, Type: p.typeName(), Pos: kind.Pos}
+	d.Names = p.varNames()
+	return d
+}
+
+// varNames parses varName (',' varName)* ';'
+func (p *parser) varNames() []string {
+	names := []string{p.identifier()}
+	for p.is(Symbol, ",") {
+		p.next()
+		names = append(names, p.identifier())
+	}
+	p.symbol(";")
+	return names
+}
+
+// subroutineDec: ('constructor' | 'function' | 'method') ('void' | type)
+// subroutineName '(' parameterList ')' subroutineBody
+func (p *parser) subroutineDec() *Subroutine {
+	kind := p.next()
+	s := &Subroutine{Kind: kind.Text, Pos: kind.Pos}
+	if p.is(Keyword, "void") {
+		s.ReturnType = p.next().Text
+	} else if p.isType() {
+		s.ReturnType = p.next().Text
+	} else {
+		p.fail("'void' or type")
+	}
+	s.Name = p.identifier()
+	p.symbol("(")
+	if !p.is(Symbol, ")") {
+		s.Params = append(s.Params, p.param())
+		for p.is(Symbol, ",") {
+			p.next()
+			s.Params = append(s.Params, p.param())
+		}
+	}
+	p.symbol(")")
+	p.symbol("{")
+	for p.is(Keyword, "var") {
+		pos := p.next().Pos
+		d := &VarDec{Type: p.typeName(), Pos: pos}
+		d.Names = p.varNames()
+		s.Vars = append(s.Vars, d)
+	}
+	s.Statements = p.statements()
+	p.symbol("}")
+	return s
+}
+
+func (p *parser) param() *Param {
+	if !p.isType() {
+		p.fail("type")
+	}
+	t := p.next()
+	return &Param{Type: t.Text, Name: p.identifier(), Pos: t.Pos}
+}
+
+// statements parses statements up to the closing '}', which is left for
+// the caller
+func (p *parser) statements() []Statement {
+	var statements []Statement
+	for !p.is(Symbol, "}") {
+		statements = append(statements, p.statement())
+	}
+	return statements
+}
+
+func (p *parser) statement() Statement {
+	if !p.is(Keyword, "let", "if", "while", "do", "return") {
+		p.fail("statement or '}'")
+	}
+	t := p.next()
+	switch t.Text {
+	case "let":
+		s := &LetStatement{Name: p.identifier(), Pos: t.Pos}
+		if p.is(Symbol, "[") {
+			p.next()
+			s.Index = p.expression()
+			p.symbol("]")
+		}
+		p.symbol("=")
+		s.Value = p.expression()
+		p.symbol(";")
+		return s
+	case "if":
+		s := &IfStatement{Cond: p.condition(), Pos: t.Pos}
+		s.Then = p.block()
+		if p.is(Keyword, "else") {
+			p.next()
+			s.HasElse = true
+			s.Else = p.block()
+		}
+		return s
+	case "while":
+		s := &WhileStatement{Cond: p.condition(), Pos: t.Pos}
+		s.Body = p.block()
+		return s
+	case "do":
+		if !p.is(Identifier) {
+			p.fail("subroutine call")
+		}
+		s := &DoStatement{Call: p.subroutineCall(p.next()), Pos: t.Pos}
+		p.symbol(";")
+		return s
+	default:
+		s := &ReturnStatement{Pos: t.Pos}
+		if !p.is(Symbol, ";") {
+			s.Value = p.expression()
+		}
+		p.symbol(";")
+		return s
+	}
+}
+
+// condition parses '(' expression ')'
+func (p *parser) condition() *Expression {
+	p.symbol("(")
+	e := p.expression()
+	p.symbol(")")
+	return e
+}
+
+// block parses '{' statements '}'
+func (p *parser) block() []Statement {
+	p.symbol("{")
+	statements := p.statements()
+	p.symbol("}")
+	return statements
+}
+
+// binaryOps are the operators of expressions
+var binaryOps = []string{"+", "-", "*", "/", "&", "|", "<", ">", "="}
+
+// expression: term (op term)*
+func (p *parser) expression() *Expression {
+	var pos vmtranslator.Position
+	if t := p.peek(); t != nil {
+		pos = t.Pos
+	}
+	e := &Expression{Term: p.term(), Pos: pos}
+	for p.is(Symbol, binaryOps...) {
+		op := p.next()
+		e.Ops = append(e.Ops, BinaryOp{Op: op.Text, Term: p.term(), Pos: op.Pos})
+	}
+	return e
+}
+
+// term: integerConstant | stringConstant | keywordConstant | varName |
+// varName '[' expression ']' | subroutineCall | '(' expression ')' |
+// unaryOp term
+func (p *parser) term() Term {
+	t := p.peek()
+	switch {
+	case p.is(IntConst):
+		p.next()
+		n, _ := strconv.Atoi(t.Text)
+		return &IntegerConstant{Value: n, Pos: t.Pos}
+	case p.is(StringConst):
+		p.next()
+		return &StringConstant{Value: t.Text, Pos: t.Pos}
+	case p.is(Keyword, "true", "false", "null", "this"):
+		p.next()
+		return &KeywordConstant{Keyword: t.Text, Pos: t.Pos}
+	case p.is(Identifier):
+		name := p.next()
+		if p.is(Symbol, "(", ".") {
+			return p.subroutineCall(name)
+		}
+		v := &VarTerm{Name: name.Text, Pos: name.Pos}
+		if p.is(Symbol, "[") {
+			p.next()
+			v.Index = p.expression()
+			p.symbol("]")
+		}
+		return v
+	case p.is(Symbol, "("):
+		p.next()
+		e := p.expression()
+		p.symbol(")")
+		return &ParenTerm{Expr: e, Pos: t.Pos}
+	case p.is(Symbol, "-", "~"):
+		p.next()
+		return &UnaryTerm{Op: t.Text, Term: p.term(), Pos: t.Pos}
+	}
+	p.fail("term")
+	return nil
+}
+
+// subroutineCall: subroutineName '(' expressionList ')' |
+// (className | varName) '.' subroutineName '(' expressionList ')'
+// where name is the first identifier, already read
+func (p *parser) subroutineCall(name Token) *SubroutineCall {
+	call := &SubroutineCall{Name: name.Text, Pos: name.Pos}
+	if p.is(Symbol, ".") {
+		p.next()
+		call.Receiver = name.Text
+		call.Name = p.identifier()
+	}
+	p.symbol("(")
+	if !p.is(Symbol, ")") {
+		call.Args = append(call.Args, p.expression())
+		for p.is(Symbol, ",") {
+			p.next()
+			call.Args = append(call.Args, p.expression())
+		}
+	}
+	p.symbol(")")
+	return call
+}
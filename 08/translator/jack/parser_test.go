@@ -0,0 +1,65 @@
+package jack
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseBody parses statements as the body of Main.main
+func parseBody(statements string) error {
+	src := "class Main {\n  function void main() {\n" + statements + "  }\n}\n"
+	_, err := Parse(strings.NewReader(src), "Main.jack")
+	return err
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing ; after let", "    let x = 1\n    return;\n", `Main.jack:4:5: expected ';': "return"`},
+		{"missing ; after do", "    do Output.println()\n  }\n", `Main.jack:4:3: expected ';': "}"`},
+		// without ; the return value is expected to start here
+		{"missing ; after return", "    return\n", `Main.jack:4:3: expected term: "}"`},
+		{"bad term", "    let x = 1 + ;\n", `Main.jack:3:17: expected term: ";"`},
+		{"keyword as term", "    let x = class;\n", `Main.jack:3:13: expected term: "class"`},
+		{"operator as term", "    let x = * 2;\n", `Main.jack:3:13: expected term: "*"`},
+		{"call without arguments", "    do f;\n", `Main.jack:3:9: expected '(': ";"`},
+		{"do without a call", "    do 3;\n", `Main.jack:3:8: expected subroutine call: "3"`},
+		{"missing )", "    let x = (1 + 2;\n", `Main.jack:3:19: expected ')': ";"`},
+		{"statement", "    x = 1;\n", `Main.jack:3:5: expected statement or '}': "x"`},
+		{"string in term", "    let x = \"a\" \"b\";\n", `Main.jack:3:17: expected ';': "\"b\""`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := parseBody(test.body)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("error = %v, want %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseClassErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"no class", "function void main() {}\n", `Main.jack:1:1: expected 'class': "function"`},
+		{"missing }", "class Main {\n  field int x;\n", `Main.jack:2:14: expected 'static', 'field', 'constructor', 'function', 'method' or '}' at end of file`},
+		{"missing ; after var", "class Main {\n  field int x\n}\n", `Main.jack:3:1: expected ';': "}"`},
+		{"bad return type", "class Main {\n  function 3 f() {}\n}\n", `Main.jack:2:12: expected 'void' or type: "3"`},
+		{"after the class", "class Main {}\nclass Other {}\n", `Main.jack:2:1: expected end of file: "class"`},
+		{"tokenizer errors first", "class Main {\n  let s = \"x;\n}\n", `Main.jack:2:11: string constant is not closed: "\"x;"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.src), "Main.jack")
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Parse(%q) error =\n%v\nwant\n%s", test.src, err, test.want)
+			}
+		})
+	}
+}
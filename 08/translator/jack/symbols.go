@@ -0,0 +1,81 @@
+package jack
+
+import "translator/vmtranslator"
+
+// varKind is where a variable lives, named like its declaration
+type varKind string
+
+const (
+	staticVar   varKind = "static"
+	fieldVar    varKind = "field"
+	argumentVar varKind = "argument"
+	localVar    varKind = "var"
+)
+
+// segments are the vm segments of the kinds of variables, fields are
+// accessed through this
+var segments = map[varKind]string{
+	staticVar:   "static",
+	fieldVar:    "this",
+	argumentVar: "argument",
+	localVar:    "local",
+}
+
+// variable is an entry of a symbolTable, Index is its index in the segment
+type variable struct {
+	Kind  varKind
+	Type  string
+	Index int
+}
+
+// symbolTable holds the variables of a class and of the subroutine being
+// compiled, the subroutine's shadow the class's
+type symbolTable struct {
+	class      map[string]variable
+	subroutine map[string]variable
+	counts     map[varKind]int
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{
+		class:      map[string]variable{},
+		subroutine: map[string]variable{},
+		counts:     map[varKind]int{},
+	}
+}
+
+// startSubroutine forgets the arguments and locals of the last subroutine
+func (t *symbolTable) startSubroutine() {
+	t.subroutine = map[string]variable{}
+	t.counts[argumentVar] = 0
+	t.counts[localVar] = 0
+}
+
+// define adds the variable name of kind and type at the next index of its
+// segment, a name can only be defined once per scope
+func (t *symbolTable) define(name, typ string, kind varKind, pos vmtranslator.Position) error {
+	scope := t.subroutine
+	if kind == staticVar || kind == fieldVar {
+		scope = t.class
+	}
+	if _, ok := scope[name]; ok {
+		return &vmtranslator.Diagnostic{Pos: pos, Token: name, Msg: "variable is already defined"}
+	}
+	scope[name] = variable{Kind: kind, Type: typ, Index: t.counts[kind]}
+	t.counts[kind]++
+	return nil
+}
+
+// lookup returns the variable name, searching the subroutine first
+func (t *symbolTable) lookup(name string) (variable, bool) {
+	if v, ok := t.subroutine[name]; ok {
+		return v, true
+	}
+	v, ok := t.class[name]
+	return v, ok
+}
+
+// count returns the number of variables of kind defined so far
+func (t *symbolTable) count(kind varKind) int {
+	return t.counts[kind]
+}
@@ -0,0 +1,164 @@
+// Package jack compiles programs of the nand2tetris Jack language into vm
+// commands for the vmtranslator package.
+package jack
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"translator/vmtranslator"
+)
+
+// TokenKind is the kind of a token, named like the tags of the project 10
+// token XML
+type TokenKind string
+
+const (
+	Keyword     TokenKind = "keyword"
+	Symbol      TokenKind = "symbol"
+	IntConst    TokenKind = "integerConstant"
+	StringConst TokenKind = "stringConstant"
+	Identifier  TokenKind = "identifier"
+)
+
+var keywords = map[string]bool{
+	"class": true, "constructor": true, "function": true, "method": true,
+	"field": true, "static": true, "var": true, "int": true, "char": true,
+	"boolean": true, "void": true, "true": true, "false": true, "null": true,
+	"this": true, "let": true, "do": true, "if": true, "else": true,
+	"while": true, "return": true,
+}
+
+const symbols = "{}()[].,;+-*/&|<>=~"
+
+// maxIntConst is the largest integer constant of the language
+const maxIntConst = 32767
+
+// Token is a token of a jack file, Text is the string constant without the
+// quotes for StringConst
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  vmtranslator.Position
+}
+
+func (t Token) String() string {
+	if t.Kind == StringConst {
+		return strconv.Quote(t.Text)
+	}
+	return t.Text
+}
+
+// Tokenize splits the jack source read from r into tokens, name is used in
+// the positions. All errors found are returned together as a
+// vmtranslator.DiagnosticList.
+func Tokenize(r io.Reader, name string) ([]Token, error) {
+	l := &lexer{name: name}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		l.lineNo++
+		l.lexLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		l.diagnostics.Add(err)
+	}
+	if l.inComment {
+		l.errorf(l.commentPos, "/*", "comment is not closed")
+	}
+	return l.tokens, l.diagnostics.Err()
+}
+
+type lexer struct {
+	name   string
+	lineNo int
+	tokens []Token
+	// inComment is set while a /* */ comment continues over lines
+	inComment   bool
+	commentPos  vmtranslator.Position
+	diagnostics vmtranslator.DiagnosticList
+}
+
+func (l *lexer) position(i int) vmtranslator.Position {
+	return vmtranslator.Position{File: l.name, Line: l.lineNo, Column: i + 1}
+}
+
+func (l *lexer) errorf(pos vmtranslator.Position, token string, format string, args ...interface{}) {
+	l.diagnostics.Add(&vmtranslator.Diagnostic{Pos: pos, Token: token, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) lexLine(line string) {
+	i := 0
+	for i < len(line) {
+		if l.inComment {
+			end := strings.Index(line[i:], "*/")
+			if end < 0 {
+				return
+			}
+			i += end + 2
+			l.inComment = false
+			continue
+		}
+
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return
+		case strings.HasPrefix(line[i:], "/*"):
+			l.inComment = true
+			l.commentPos = l.position(i)
+			i += 2
+		case strings.IndexByte(symbols, c) >= 0:
+			l.add(Symbol, string(c), i)
+			i++
+		case c == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end < 0 {
+				l.errorf(l.position(i), line[i:], "string constant is not closed")
+				return
+			}
+			l.add(StringConst, line[i+1:i+1+end], i)
+			i += end + 2
+		case isDigit(c):
+			start := i
+			for i < len(line) && isDigit(line[i]) {
+				i++
+			}
+			text := line[start:i]
+			if n, err := strconv.Atoi(text); err != nil || n > maxIntConst {
+				l.errorf(l.position(start), text, "integer constant is larger than %d", maxIntConst)
+			}
+			l.add(IntConst, text, start)
+		case isLetter(c):
+			start := i
+			for i < len(line) && (isLetter(line[i]) || isDigit(line[i])) {
+				i++
+			}
+			text := line[start:i]
+			if keywords[text] {
+				l.add(Keyword, text, start)
+			} else {
+				l.add(Identifier, text, start)
+			}
+		default:
+			l.errorf(l.position(i), string(c), "unexpected character")
+			i++
+		}
+	}
+}
+
+func (l *lexer) add(kind TokenKind, text string, i int) {
+	l.tokens = append(l.tokens, Token{kind, text, l.position(i)})
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
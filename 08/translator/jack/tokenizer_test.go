@@ -0,0 +1,33 @@
+package jack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unterminated string", "let s = \"abc;\n", `Main.jack:1:9: string constant is not closed: "\"abc;"`},
+		{"string over two lines", "do f(\"a\nb\");\n", `Main.jack:1:6: string constant is not closed: "\"a"` + "\n" +
+			`Main.jack:2:2: string constant is not closed: "\");"`},
+		{"unterminated comment", "class Main {\n  /* no end\n}\n", `Main.jack:2:3: comment is not closed: "/*"`},
+		{"unterminated doc comment", "/** API\n * of Main\n", `Main.jack:1:1: comment is not closed: "/*"`},
+		{"integer too large", "let x = 32768;\n", `Main.jack:1:9: integer constant is larger than 32767: "32768"`},
+		{"unexpected character", "let x = y # 2;\n", `Main.jack:1:11: unexpected character: "#"`},
+		{"all errors", "let a = 99999;\nlet b = $;\n/*\n", `Main.jack:1:9: integer constant is larger than 32767: "99999"` + "\n" +
+			`Main.jack:2:9: unexpected character: "$"` + "\n" +
+			`Main.jack:3:1: comment is not closed: "/*"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Tokenize(strings.NewReader(test.src), "Main.jack")
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Tokenize(%q) error =\n%v\nwant\n%s", test.src, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package jack
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+// WriteTokens writes tokens in the project 10 token XML format, e.g.
+// `<keyword> class </keyword>` per line inside a tokens element
+func WriteTokens(w io.Writer, tokens []Token) error {
+	x := &xmlWriter{w: bufio.NewWriter(w)}
+	x.w.WriteString("<tokens>\n")
+	for _, t := range tokens {
+		x.terminal(t.Kind, t.Text)
+	}
+	x.w.WriteString("</tokens>\n")
+	return x.w.Flush()
+}
+
+// WriteClass writes the parse tree of class in the project 10 XML format,
+// one element per grammar rule with the tokens as terminal elements
+func WriteClass(w io.Writer, class *Class) error {
+	x := &xmlWriter{w: bufio.NewWriter(w)}
+	x.class(class)
+	return x.w.Flush()
+}
+
+// xmlWriter writes elements indented by two spaces per level
+type xmlWriter struct {
+	w      *bufio.Writer
+	indent string
+}
+
+func (x *xmlWriter) open(tag string) {
+	x.w.WriteString(x.indent + "<" + tag + ">\n")
+	x.indent += "  "
+}
+
+func (x *xmlWriter) close(tag string) {
+	x.indent = x.indent[2:]
+	x.w.WriteString(x.indent + "</" + tag + ">\n")
+}
+
+// terminal writes a token on its own line
+func (x *xmlWriter) terminal(kind TokenKind, text string) {
+	x.w.WriteString(x.indent + "<" + string(kind) + "> ")
+	x.w.WriteString(xmlEscaper.Replace(text))
+	x.w.WriteString(" </" + string(kind) + ">\n")
+}
+
+func (x *xmlWriter) keyword(text string) {
+	x.terminal(Keyword, text)
+}
+
+func (x *xmlWriter) symbol(text string) {
+	x.terminal(Symbol, text)
+}
+
+func (x *xmlWriter) identifier(text string) {
+	x.terminal(Identifier, text)
+}
+
+// typeName writes a type, which is a keyword for the primitive types and
+// void and a class name otherwise
+func (x *xmlWriter) typeName(name string) {
+	if keywords[name] {
+		x.keyword(name)
+	} else {
+		x.identifier(name)
+	}
+}
+
+func (x *xmlWriter) class(c *Class) {
+	x.open("class")
+	x.keyword("class")
+	x.identifier(c.Name)
+	x.symbol("{")
+	for _, d := range c.Vars {
+		x.open("classVarDec")
+		x.keyword(d.Kind)
+		x.typeName(d.Type)
+		x.varNames(d.Names)
+		x.close("classVarDec")
+	}
+	for _, s := range c.Subroutines {
+		x.subroutine(s)
+	}
+	x.symbol("}")
+	x.close("class")
+}
+
+// varNames writes the names separated by commas and the closing semicolon
+func (x *xmlWriter) varNames(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			x.symbol(",")
+		}
+		x.identifier(name)
+	}
+	x.symbol(";")
+}
+
+func (x *xmlWriter) subroutine(s *Subroutine) {
+	x.open("subroutineDec")
+	x.keyword(s.Kind)
+	x.typeName(s.ReturnType)
+	x.identifier(s.Name)
+	x.symbol("(")
+	x.open("parameterList")
+	for i, param := range s.Params {
+		if i > 0 {
+			x.symbol(",")
+		}
+		x.typeName(param.Type)
+		x.identifier(param.Name)
+	}
+	x.close("parameterList")
+	x.symbol(")")
+	x.open("subroutineBody")
+	x.symbol("{")
+	for _, d := range s.Vars {
+		x.open("varDec")
+		x.keyword("var")
+		x.typeName(d.Type)
+		x.varNames(d.Names)
+		x.close("varDec")
+	}
+	x.statements(s.Statements)
+	x.symbol("}")
+	x.close("subroutineBody")
+	x.close("subroutineDec")
+}
+
+func (x *xmlWriter) statements(statements []Statement) {
+	x.open("statements")
+	for _, s := range statements {
+		x.statement(s)
+	}
+	x.close("statements")
+}
+
+// block writes statements in braces
+func (x *xmlWriter) block(statements []Statement) {
+	x.symbol("{")
+	x.statements(statements)
+	x.symbol("}")
+}
+
+// condition writes an expression in parentheses
+func (x *xmlWriter) condition(e *Expression) {
+	x.symbol("(")
+	x.expression(e)
+	x.symbol(")")
+}
+
+func (x *xmlWriter) statement(s Statement) {
+	switch s := s.(type) {
+	case *LetStatement:
+		x.open("letStatement")
+		x.keyword("let")
+		x.identifier(s.Name)
+		if s.Index != nil {
+			x.symbol("[")
+			x.expression(s.Index)
+			x.symbol("]")
+		}
+		x.symbol("=")
+		x.expression(s.Value)
+		x.symbol(";")
+		x.close("letStatement")
+	case *IfStatement:
+		x.open("ifStatement")
+		x.keyword("if")
+		x.condition(s.Cond)
+		x.block(s.Then)
+		if s.HasElse {
+			x.keyword("else")
+			x.block(s.Else)
+		}
+		x.close("ifStatement")
+	case *WhileStatement:
+		x.open("whileStatement")
+		x.keyword("while")
+		x.condition(s.Cond)
+		x.block(s.Body)
+		x.close("whileStatement")
+	case *DoStatement:
+		x.open("doStatement")
+		x.keyword("do")
+		x.subroutineCall(s.Call)
+		x.symbol(";")
+		x.close("doStatement")
+	case *ReturnStatement:
+		x.open("returnStatement")
+		x.keyword("return")
+		if s.Value != nil {
+			x.expression(s.Value)
+		}
+		x.symbol(";")
+		x.close("returnStatement")
+	}
+}
+
+func (x *xmlWriter) expression(e *Expression) {
+	x.open("expression")
+	x.term(e.Term)
+	for _, op := range e.Ops {
+		x.symbol(op.Op)
+		x.term(op.Term)
+	}
+	x.close("expression")
+}
+
+func (x *xmlWriter) term(t Term) {
+	x.open("term")
+	switch t := t.(type) {
+	case *IntegerConstant:
+		x.terminal(IntConst, strconv.Itoa(t.Value))
+	case *StringConstant:
+		x.terminal(StringConst, t.Value)
+	case *KeywordConstant:
+		x.keyword(t.Keyword)
+	case *VarTerm:
+		x.identifier(t.Name)
+		if t.Index != nil {
+			x.symbol("[")
+			x.expression(t.Index)
+			x.symbol("]")
+		}
+	case *SubroutineCall:
+		x.subroutineCall(t)
+	case *ParenTerm:
+		x.symbol("(")
+		x.expression(t.Expr)
+		x.symbol(")")
+	case *UnaryTerm:
+		x.symbol(t.Op)
+		x.term(t.Term)
+	}
+	x.close("term")
+}
+
+// subroutineCall writes the tokens of a call, it has no element of its own
+func (x *xmlWriter) subroutineCall(call *SubroutineCall) {
+	if call.Receiver != "" {
+		x.identifier(call.Receiver)
+		x.symbol(".")
+	}
+	x.identifier(call.Name)
+	x.symbol("(")
+	x.open("expressionList")
+	for i, arg := range call.Args {
+		if i > 0 {
+			x.symbol(",")
+		}
+		x.expression(arg)
+	}
+	x.close("expressionList")
+	x.symbol(")")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// analyzerPrograms are the project 10 programs, the output of the jack
+// front end for each of their .jack files is compared with the expected
+// <Name>T.xml and <Name>.xml files next to them
+var analyzerPrograms = []string{
+	"10/ArrayTest",
+	"10/ExpressionLessSquare",
+	"10/Square",
+}
+
+// TestTokenize compares the token XML of every analyzer program file with
+// the expected <Name>T.xml
+func TestTokenize(t *testing.T) {
+	testAnalyzer(t, "T.xml", tokenizeFile)
+}
+
+// TestParseTree compares the parse tree XML of every analyzer program file
+// with the expected <Name>.xml
+func TestParseTree(t *testing.T) {
+	testAnalyzer(t, ".xml", parseTreeFile)
+}
+
+// testAnalyzer compares the xml produced for each jack file of the analyzer
+// programs with the expected <Name><suffix> file next to it after
+// normalizeXML
+func testAnalyzer(t *testing.T, suffix string, produce func(path string) ([]byte, error)) {
+	for _, program := range analyzerPrograms {
+		paths, err := jackPaths(filepath.Join(root, program))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			t.Run(filepath.Join(program, filepath.Base(path)), func(t *testing.T) {
+				got, err := produce(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantPath := xmlOutputPath(path, filepath.Dir(path), suffix)
+				want, err := os.ReadFile(wantPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				normalizedWant, normalizedGot := normalizeXML(string(want)), normalizeXML(string(got))
+				if normalizedWant != normalizedGot {
+					var diff strings.Builder
+					writeDiff(&diff, wantPath, path, normalizedWant, normalizedGot)
+					t.Error(diff.String())
+				}
+			})
+		}
+	}
+}
+
+// normalizeXML drops the indentation, line endings and empty lines of xml,
+// the official compare tools ignore those
+func normalizeXML(xml string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(xml, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// TestXMLOutputPath checks that the analyzer commands don't write over the
+// expected xml files of project 10 by default
+func TestXMLOutputPath(t *testing.T) {
+	tests := []struct {
+		path, dir, suffix string
+		want              string
+	}{
+		{"10/Square/Square.jack", "", "T.xml", "10/Square/out/SquareT.xml"},
+		{"10/Square/Square.jack", "tmp", "T.xml", "tmp/SquareT.xml"},
+		{"10/Square/Square.jack", "", ".xml", "10/Square/out/Square.xml"},
+		{"10/Square/Square.jack", "tmp", ".xml", "tmp/Square.xml"},
+	}
+	for _, test := range tests {
+		if got := xmlOutputPath(test.path, test.dir, test.suffix); got != test.want {
+			t.Errorf("xmlOutputPath(%q, %q, %q) = %s, want %s", test.path, test.dir, test.suffix, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"translator/vmtranslator"
+)
+
+// searchPath is a flag that collects directories, it can be repeated and
+// takes lists separated by the OS path list separator
+type searchPath []string
+
+func (p *searchPath) String() string {
+	return strings.Join(*p, string(os.PathListSeparator))
+}
+
+func (p *searchPath) Set(value string) error {
+	*p = append(*p, filepath.SplitList(value)...)
+	return nil
+}
+
+// osFlag defines the -os flag on flags
+func osFlag(flags *flag.FlagSet) *searchPath {
+	var dirs searchPath
+	flags.Var(&dirs, "os",
+		"directory of OS classes (.jack or .vm files, e.g. ../../12) to link the functions the program calls but doesn't define from, can be repeated")
+	return &dirs
+}
+
+// linkOS adds the classes of the OS directories dirs that define functions
+// the modules call but don't define, then the ones those classes call, and
+// Sys for the bootstrap code if no module defines Sys.init. Calls of
+// intrinsics don't need a class if intrinsics is set. It returns the
+// modules with the linked classes appended and the linked classes. Calls no
+// module or OS class defines are returned together as a DiagnosticList.
+func linkOS(modules []vmtranslator.Module, dirs []string, intrinsics bool) ([]vmtranslator.Module, []vmtranslator.Module, error) {
+	library, err := libraryClasses(dirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defined := map[string]bool{}
+	classes := map[string]bool{}
+	var calls []vmtranslator.Command
+	add := func(module vmtranslator.Module) {
+		classes[module.Name] = true
+		for _, c := range module.Commands {
+			switch c.Type {
+			case vmtranslator.C_FUNCTION:
+				defined[c.Arg1] = true
+			case vmtranslator.C_CALL:
+				calls = append(calls, c)
+			}
+		}
+	}
+	for _, module := range modules {
+		add(module)
+	}
+
+	var diagnostics vmtranslator.DiagnosticList
+	var linked []vmtranslator.Module
+	// load adds the OS class, it reports whether there is one
+	load := func(class string) bool {
+		path, ok := library[class]
+		if !ok || classes[class] {
+			return false
+		}
+		module, err := loadLibraryClass(path)
+		classes[class] = true
+		if err != nil {
+			diagnostics.Add(err)
+			return false
+		}
+		add(module)
+		linked = append(linked, module)
+		return true
+	}
+	if !defined["Sys.init"] {
+		load("Sys")
+	}
+	for len(calls) > 0 {
+		c := calls[0]
+		calls = calls[1:]
+		if defined[c.Arg1] || intrinsics && vmtranslator.IsIntrinsic(c.Arg1, c.Arg2) {
+			continue
+		}
+		if class, _, ok := strings.Cut(c.Arg1, "."); ok && load(class) && defined[c.Arg1] {
+			continue
+		}
+		diagnostics.Add(&vmtranslator.Diagnostic{Pos: c.Arg1Pos, Token: c.Arg1,
+			Msg: "function is not defined in the program or the OS"})
+	}
+	return append(modules, linked...), linked, diagnostics.Err()
+}
+
+// libraryClasses returns the path of each class in dirs by name, the first
+// directory that has a class wins and a .jack file is preferred over a .vm
+// file of the same class
+func libraryClasses(dirs []string) (map[string]string, error) {
+	library := map[string]string{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		found := map[string]string{}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || ext != ".jack" && ext != ".vm" {
+				continue
+			}
+			class := strings.TrimSuffix(entry.Name(), ext)
+			if _, ok := found[class]; !ok || ext == ".jack" {
+				found[class] = filepath.Join(dir, entry.Name())
+			}
+		}
+		for class, path := range found {
+			if _, ok := library[class]; !ok {
+				library[class] = path
+			}
+		}
+	}
+	return library, nil
+}
+
+// loadLibraryClass compiles or parses the OS class at path
+func loadLibraryClass(path string) (vmtranslator.Module, error) {
+	if strings.HasSuffix(path, ".vm") {
+		return parseFile(path)
+	}
+	modules, err := compileProgram([]string{path})
+	if err != nil {
+		return vmtranslator.Module{}, err
+	}
+	return modules[0], nil
+}
+
+// printLinked lists the linked OS classes and warns about their functions
+// that don't end in a return, like the empty stubs of an unfinished OS,
+// which would run on into the next function
+func printLinked(linked []vmtranslator.Module) {
+	if len(linked) == 0 {
+		return
+	}
+	names := make([]string, len(linked))
+	for i, module := range linked {
+		names[i] = module.Name
+	}
+	fmt.Printf("linked %d OS classes: %s\n", len(linked), strings.Join(names, ", "))
+	for _, module := range linked {
+		for _, f := range fallThroughFunctions(module) {
+			fmt.Printf("warning: %s: %s doesn't end in a return, it is probably a stub\n", f.Pos, f.Arg1)
+		}
+	}
+}
+
+// fallThroughFunctions returns the function commands of the module whose
+// last command is not a return or goto
+func fallThroughFunctions(module vmtranslator.Module) []vmtranslator.Command {
+	var functions []vmtranslator.Command
+	for i, c := range module.Commands {
+		if c.Type != vmtranslator.C_FUNCTION {
+			continue
+		}
+		end := i + 1
+		for end < len(module.Commands) && module.Commands[end].Type != vmtranslator.C_FUNCTION {
+			end++
+		}
+		last := module.Commands[end-1].Type
+		if last != vmtranslator.C_RETURN && last != vmtranslator.C_GOTO {
+			functions = append(functions, c)
+		}
+	}
+	return functions
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"translator/vmtranslator"
+)
+
+// TestLink links every compiler program with the OS classes of 12, every
+// call has to resolve
+func TestLink(t *testing.T) {
+	for _, program := range compilerPrograms {
+		t.Run(program, func(t *testing.T) {
+			modules := compileTestProgram(t, program)
+			modules, _, err := linkOS(modules, []string{filepath.Join(root, "12")}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vmtranslator.Validate(modules); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestLinkUndefined checks that the calls no module or OS class defines are
+// reported at the call, also when the OS has the class but not the function
+func TestLinkUndefined(t *testing.T) {
+	src := "function Sys.init 0\ncall Main.missing 0\npop temp 0\ncall Foo.bar 0\npop temp 0\n" +
+		"call Math.nothing 1\npop temp 0\nlabel END\ngoto END\n"
+	commands, err := vmtranslator.Parse(strings.NewReader(src), "Sys.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := []vmtranslator.Module{{Name: "Sys", Commands: commands}}
+	_, linked, err := linkOS(modules, []string{filepath.Join(root, "12")}, false)
+	want := `Sys.vm:2:6: function is not defined in the program or the OS: "Main.missing"` + "\n" +
+		`Sys.vm:4:6: function is not defined in the program or the OS: "Foo.bar"` + "\n" +
+		`Sys.vm:6:6: function is not defined in the program or the OS: "Math.nothing"`
+	if err == nil || err.Error() != want {
+		t.Errorf("error =\n%v\nwant\n%s", err, want)
+	}
+	// Sys.init is defined, Math is linked for Math.nothing
+	if names := moduleNames(linked); !reflect.DeepEqual(names, []string{"Math"}) {
+		t.Errorf("linked %q, want Math", names)
+	}
+}
+
+// TestLinkClasses checks which OS classes are linked into the compiler
+// programs and in which order: Sys first, then the classes as their calls
+// come up
+func TestLinkClasses(t *testing.T) {
+	tests := []struct {
+		program    string
+		intrinsics bool
+		want       []string
+	}{
+		{"11/Seven", false, []string{"Sys", "Math", "Output", "Array"}},
+		// Math.multiply is an intrinsic, Output.init still needs Array
+		{"11/Seven", true, []string{"Sys", "Output", "Array"}},
+		{"11/Average", false, []string{"Sys", "String", "Keyboard", "Array", "Output", "Math", "Memory"}},
+	}
+	for _, test := range tests {
+		t.Run(test.program, func(t *testing.T) {
+			modules := compileTestProgram(t, test.program)
+			_, linked, err := linkOS(modules, []string{filepath.Join(root, "12")}, test.intrinsics)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := moduleNames(linked); !reflect.DeepEqual(names, test.want) {
+				t.Errorf("linked %q, want %q", names, test.want)
+			}
+		})
+	}
+}
+
+func moduleNames(modules []vmtranslator.Module) []string {
+	var names []string
+	for _, module := range modules {
+		names = append(names, module.Name)
+	}
+	return names
+}
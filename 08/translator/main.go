@@ -2,593 +2,320 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
-)
-
-type command string
 
-const stackPointerDefault = 256
-
-const (
-	C_ARITHMETIC command = "C_ARITHMETIC"
-	C_PUSH       command = "C_PUSH"
-	C_POP        command = "C_POP"
-	C_LABEL      command = "C_LABEL"
-	C_GOTO       command = "C_GOTO"
-	C_IF         command = "C_IF"
-	C_FUNCTION   command = "C_FUNCTION"
-	C_RETURN     command = "C_RETURN"
-	C_CALL       command = "C_CALL"
-)
-
-type segment string
-
-const (
-	argument segment = "argument"
-	local    segment = "local"
-	static   segment = "static"
-	constant segment = "constant"
-	this     segment = "this"
-	that     segment = "that"
-	pointer  segment = "pointer"
-	temp     segment = "temp"
+	"translator/assembler"
+	"translator/emulator"
+	"translator/vmtranslator"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	args := os.Args[1:]
-	fmt.Printf("args: %+v\n", args)
-	filePath := args[0]
-	asmPath := filePath + ".asm"
-	var parsers []*parser
-	// if filename has .vm extension, then it's a single file
-	if len(filePath) > 3 && filePath[len(filePath)-3:] == ".vm" {
-		fileName := filePath[:len(filePath)-3]
-		asmPath = fileName + ".asm"
-		parsers = append(parsers, newParser(filePath))
-	} else {
-		fileName := strings.Split(filePath, "/")[len(strings.Split(filePath, "/"))-1]
-		asmPath = filePath + "/" + fileName + ".asm"
-		files, err := ioutil.ReadDir(filePath)
-		if err != nil {
-			log.Fatal(err)
-		}
-		for _, file := range files {
-			if !file.IsDir() && strings.HasSuffix(file.Name(), ".vm") {
-				parsers = append(parsers, newParser(filePath+"/"+file.Name()))
-			}
-		}
-
-	}
-	fmt.Println("asm path: ", asmPath)
-
-	codeWriter := newCodeWriter(asmPath)
-	codeWriter.writeInit()
-	for _, parser := range parsers {
-		codeWriter.setFileName(parser.getFileName())
-		fmt.Printf("vm fileName: %s\n", parser.getFileName())
-		for parser.advance() {
-			cmd := parser.commandType()
-			line := parser.getLine()
-			arg1 := parser.arg1(cmd)
-			arg2 := parser.arg2(cmd)
-			fmt.Printf("line: %s - cmd: %s arg1: %s arg2: %d\n",
-				line, cmd, arg1, arg2)
-			if cmd == C_ARITHMETIC {
-				codeWriter.writeArithmetic(arg1)
-			} else if cmd == C_PUSH || cmd == C_POP {
-				codeWriter.writePushPop(cmd, arg1, arg2)
-			} else if cmd == C_LABEL {
-				codeWriter.writeLabel(arg1)
-			} else if cmd == C_GOTO {
-				codeWriter.writeGoto(arg1)
-			} else if cmd == C_IF {
-				codeWriter.writeIf(arg1)
-			} else if cmd == C_FUNCTION {
-				codeWriter.writeFunction(arg1, arg2)
-			} else if cmd == C_CALL {
-				codeWriter.writeCall(arg1, arg2)
-			} else if cmd == C_RETURN {
-				codeWriter.writeReturn()
-			} else {
-				log.Fatal("codeWriter not implemented for command: ", cmd)
-			}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "regress":
+			runRegress(os.Args[2:])
+			return
+		case "run":
+			runRun(os.Args[2:])
+			return
+		case "test":
+			runTest(os.Args[2:])
+			return
+		case "assemble":
+			runAssemble(os.Args[2:])
+			return
+		case "fuzz":
+			runFuzz(os.Args[2:])
+			return
+		case "tokenize":
+			runTokenize(os.Args[2:])
+			return
+		case "parse":
+			runParse(os.Args[2:])
+			return
+		case "build":
+			runBuild(os.Args[2:])
+			return
 		}
 	}
-	codeWriter.close()
+	runTranslate(os.Args[1:])
 }
 
-type parser struct {
-	file    *os.File
-	scanner *bufio.Scanner
-}
+// runTranslate translates a .vm file or a directory of .vm files into an
+// .asm or .hack file next to it
+func runTranslate(args []string) {
+	flags := flag.NewFlagSet("translator", flag.ExitOnError)
+	translateOptions := translateFlags(flags)
+	osDirs := osFlag(flags)
+	emit := flags.String("emit", "asm", "output to write: asm (Hack assembly) or hack (machine code)")
+	sourceMap := flags.Bool("source-map", false,
+		"also write <program>.map.json with the vm file, line, command and function of every ROM address")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatal("usage: translator [--profile=stage1|full] [--bootstrap=auto|always|never] [--emit=asm|hack] [-source-map] [-O] [-Ovm] [-remove-dead] [-shared-routines] [-safe-compare=false] [-intrinsics] [-os dir] <file.vm|dir>\n" +
+			"       translator regress [-update] [-v]\n" +
+			"       translator run [-interpret] [-cycles n] [-set addr=value,...] <file.vm|dir> [addr|from-to ...]\n" +
+			"       translator test [-vm] [-out dir] <dir|file.tst>\n" +
+			"       translator assemble <file.asm> ...\n" +
+			"       translator fuzz [-n programs] [-seed s] [-size statements] [-out dir]\n" +
+			"       translator tokenize [-out dir] <file.jack|dir>\n" +
+			"       translator parse [-out dir] <file.jack|dir>\n" +
+			"       translator build [--emit=vm|asm|hack] [-os dir] [translator flags] <dir>")
+	}
 
-func newParser(path string) *parser {
-	file, err := os.Open(path)
+	args = flags.Args()
+	fmt.Printf("args: %+v\n", args)
+	paths, asmPath, err := programPaths(args[0])
 	if err != nil {
 		log.Fatal(err)
 	}
-	scanner := bufio.NewScanner(file)
-	return &parser{file, scanner}
-}
+	fmt.Println("asm path: ", asmPath)
 
-func (p *parser) getFileName() string {
-	asd := regexp.MustCompile(`([^/]+)\.vm$`)
-	matches := asd.FindStringSubmatch(p.file.Name())
-	if len(matches) > 1 {
-		return matches[1]
-	} else {
-		log.Fatal("could not parse filename from path: ", p.file.Name())
-		return ""
+	modules, err := parseProgram(paths)
+	if err != nil {
+		reportAndExit(err)
 	}
-}
-
-func (p *parser) advance() bool {
-	for {
-		ok := p.scanner.Scan()
-		if !ok {
-			return false
-		}
-		cmd := p.scanner.Text()
-		if cmd == "" || cmd[:2] == "//" {
-			continue
+	if len(*osDirs) > 0 {
+		var linked []vmtranslator.Module
+		modules, linked, err = linkOS(modules, *osDirs, translateOptions().Intrinsics)
+		if err != nil {
+			reportAndExit(err)
 		}
-		return true
+		printLinked(linked)
 	}
-}
 
-func (p *parser) getLine() string {
-	return p.scanner.Text()
+	if err := writeProgram(asmPath, modules, translateOptions(), *emit, *sourceMap); err != nil {
+		reportAndExit(err)
+	}
 }
 
-func (p *parser) commandType() command {
-	cmd := p.scanner.Text()
-	cmd = strings.Split(cmd, " ")[0]
-	if cmd == "add" || cmd == "sub" || cmd == "neg" || cmd == "eq" || cmd == "gt" || cmd == "lt" || cmd == "and" || cmd == "or" || cmd == "not" {
-		return C_ARITHMETIC
-	} else if cmd == "push" {
-		return C_PUSH
-	} else if cmd == "pop" {
-		return C_POP
-	} else if cmd == "label" {
-		return C_LABEL
-	} else if cmd == "goto" {
-		return C_GOTO
-	} else if cmd == "if-goto" {
-		return C_IF
-	} else if cmd == "function" {
-		return C_FUNCTION
-	} else if cmd == "call" {
-		return C_CALL
-	} else if cmd == "return" {
-		return C_RETURN
-	} else {
-		// TODO implement C_LABEL, C_GOTO, C_IF, C_FUNCTION, C_RETURN, and C_CALL
-		log.Fatalf("cmd not implemented: %s", cmd)
-		return ""
+// writeProgram translates the modules into asmPath, or into the .hack file
+// next to it for emit "hack", writes the source map next to it if asked
+// for and prints the stats
+func writeProgram(asmPath string, modules []vmtranslator.Module, opts vmtranslator.Options, emit string, sourceMap bool) error {
+	var stats vmtranslator.Stats
+	opts.Stats = &stats
+	var m vmtranslator.SourceMap
+	if sourceMap {
+		opts.SourceMap = &m
+	}
+	outPath := asmPath
+	var err error
+	switch emit {
+	case "asm":
+		err = writeAsmFile(asmPath, modules, opts)
+	case "hack":
+		outPath = strings.TrimSuffix(asmPath, ".asm") + ".hack"
+		err = writeHackProgram(outPath, modules, opts)
+	default:
+		return fmt.Errorf("unknown -emit value: %s", emit)
+	}
+	if err != nil {
+		return err
 	}
+	if sourceMap {
+		if err := writeSourceMap(strings.TrimSuffix(asmPath, ".asm")+".map.json", outPath, m); err != nil {
+			return err
+		}
+	}
+	printStats(stats, opts.Optimize || opts.OptimizeVM)
+	return nil
 }
 
-func (p *parser) arg1(cmd command) string {
-	line := p.scanner.Text()
-	split := strings.Split(line, " ")
-	if cmd == C_ARITHMETIC {
-		return split[0]
-	} else {
-		if len(split) > 1 {
-			return split[1]
-		} else {
-			return ""
+// translateFlags defines the flags that configure the translation on flags,
+// the returned function builds the options after parsing
+func translateFlags(flags *flag.FlagSet) func() vmtranslator.Options {
+	profile := flags.String("profile", string(vmtranslator.ProfileFull),
+		"vm language to translate: stage1 (project 07, arithmetic and memory access only) or full (project 08)")
+	bootstrap := flags.String("bootstrap", string(vmtranslator.BootstrapAuto),
+		"write the bootstrap code: auto (only if Sys.init is defined), always or never, stage1 never writes it")
+	optimize := flags.Bool("O", false, "run the peephole optimizer over the generated assembly")
+	optimizeVM := flags.Bool("Ovm", false,
+		"fold constants and simplify the vm commands before translating them")
+	removeDead := flags.Bool("remove-dead", false,
+		"leave out the functions Sys.init can't reach through calls")
+	shared := flags.Bool("shared-routines", false,
+		"jump to one shared copy of the call, return and comparison code instead of inlining it")
+	safeCompare := flags.Bool("safe-compare", true,
+		"check the signs in gt and lt so they are right when x-y overflows, false only subtracts")
+	intrinsics := flags.Bool("intrinsics", false,
+		"replace the calls of Math.multiply, Math.divide, Memory.peek and Memory.poke with assembly routines of the translator")
+	return func() vmtranslator.Options {
+		return vmtranslator.Options{
+			Profile:             vmtranslator.Profile(*profile),
+			Bootstrap:           vmtranslator.Bootstrap(*bootstrap),
+			Optimize:            *optimize,
+			OptimizeVM:          *optimizeVM,
+			RemoveDeadFunctions: *removeDead,
+			SharedRoutines:      *shared,
+			FastCompare:         !*safeCompare,
+			Intrinsics:          *intrinsics,
 		}
 	}
 }
 
-func (p *parser) arg2(cmd command) int {
-	line := p.scanner.Text()
-	if cmd == C_PUSH || cmd == C_POP || cmd == C_FUNCTION || cmd == C_CALL {
-		re := regexp.MustCompile(`\s`)
-		split := re.Split(string(line), -1)
-		if len(split) < 3 {
-			log.Fatalf("arg2: not enough arguments on line: %s", line)
+// printStats reports the ROM words the program needs and the functions that
+// were left out
+func printStats(stats vmtranslator.Stats, optimized bool) {
+	if len(stats.Removed) > 0 {
+		saved := 0
+		for _, f := range stats.Removed {
+			saved += f.Instructions
 		}
-		argStr := split[2]
-		arg, err := strconv.Atoi(argStr)
-		if err != nil {
-			log.Fatalf("arg2: %s is not a number on line: %s", argStr, line)
+		fmt.Printf("removed %d unreachable functions, saving %d instructions:\n", len(stats.Removed), saved)
+		for _, f := range stats.Removed {
+			fmt.Printf("  %s (%s.vm): %d\n", f.Name, f.Module, f.Instructions)
 		}
-		return arg
+	}
+	if optimized {
+		saved := stats.Instructions - stats.Optimized
+		fmt.Printf("instructions: %d, optimized: %d (-%d, %.1f%%)\n", stats.Instructions,
+			stats.Optimized, saved, 100*float64(saved)/float64(stats.Instructions))
 	} else {
-		return -1
+		fmt.Printf("instructions: %d\n", stats.Instructions)
+	}
+	if stats.Optimized > emulator.ROMSize {
+		fmt.Printf("warning: the program doesn't fit into the %d words of ROM\n", emulator.ROMSize)
 	}
 }
 
-type codeWriter struct {
-	file       *os.File
-	vmFileName string
-	stackIndex int
-	cmdCount   int
-}
-
-func newCodeWriter(fileName string) *codeWriter {
-	file, err := os.Create(fileName)
+// writeSourceMap writes the source map of the program at programPath to the
+// JSON file mapPath
+func writeSourceMap(mapPath, programPath string, m vmtranslator.SourceMap) error {
+	file, err := os.Create(mapPath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	return &codeWriter{file, "", stackPointerDefault, 0}
-}
-
-func (c *codeWriter) writeInit() {
-	c.writeCommand("// ** start init\n")
-	initStack := "@256\nD=A\n@SP\nM=D\n"
-	c.writeCommand(initStack)
-	c.writeCall("Sys.init", 0)
-	c.writeCommand("// ** end init\n")
-}
-
-func (c *codeWriter) setFileName(fileName string) {
-	c.vmFileName = fileName
+	w := bufio.NewWriter(file)
+	err = m.WriteJSON(w, filepath.Base(programPath))
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-func (c *codeWriter) writeCommand(cmd string) {
-	c.file.WriteString(cmd)
-	c.cmdCount++
+// writeAsmFile translates the modules into the assembly file asmPath, the
+// file is removed again if the translation fails
+func writeAsmFile(asmPath string, modules []vmtranslator.Module, opts vmtranslator.Options) error {
+	file, err := os.Create(asmPath)
+	if err != nil {
+		return err
+	}
+	var diagnostics vmtranslator.DiagnosticList
+	w := bufio.NewWriter(file)
+	diagnostics.Add(vmtranslator.Translate(modules, w, opts))
+	diagnostics.Add(w.Flush())
+	diagnostics.Add(file.Close())
+	if len(diagnostics) > 0 {
+		os.Remove(asmPath)
+	}
+	return diagnostics.Err()
 }
 
-func (c *codeWriter) writeArithmetic(cmd string) {
-	cmdCount := strconv.Itoa(c.cmdCount)
-	getStackTop := "@SP\n" +
-		"M=M-1\n" +
-		"A=M\n"
-
-	incrStack := "@SP\n" +
-		"M=M+1\n"
-
-	alu2ParamCommand := getStackTop +
-		"D=M\n" +
-		"@SP\n" +
-		"M=M-1\n" +
-		"A=M\n" +
-		"M=%s\n" + // M=M+D, M=M-D, M=M&D, M=M|D
-		incrStack + "\n"
-
-	alu1ParamCommand := getStackTop +
-		"M=%s\n" + // M=-M, M=!M
-		incrStack + "\n"
-
-	cmpCommand := getStackTop +
-		"D+M\n" +
-		getStackTop +
-		"D=M-D\n" +
-		"@CMD" + cmdCount + "\n" +
-		"D;%s\n" + // JEQ, JGT, JLT
-		"@SP\n" +
-		"A=M\n" +
-		"M=0\n" +
-		"@END" + cmdCount + "\n" +
-		"0;JMP\n" +
-		"(CMD" + cmdCount + ")\n" +
-		"@SP\n" +
-		"A=M\n" +
-		"M=-1\n" +
-		"(END" + cmdCount + ")\n" +
-		incrStack
-
-	var asmC string
-	switch cmd {
-	case "add":
-		asmC = "// add\n"
-		asmC += fmt.Sprintf(alu2ParamCommand, "M+D")
-	case "sub":
-		asmC = "// sub\n"
-		asmC += fmt.Sprintf(alu2ParamCommand, "M-D")
-	case "neg":
-		asmC = "// neg\n"
-		asmC += fmt.Sprintf(alu1ParamCommand, "-M")
-	case "eq":
-		asmC = "// eq\n"
-		asmC += fmt.Sprintf(cmpCommand, "JEQ")
-	case "gt": // x > y
-		asmC = "// gt\n"
-		asmC += fmt.Sprintf(cmpCommand, "JGT")
-	case "lt": // x < y
-		asmC = "// lt\n"
-		asmC += fmt.Sprintf(cmpCommand, "JLT")
-	case "and":
-		asmC = "// and\n"
-		asmC += fmt.Sprintf(alu2ParamCommand, "M&D")
-	case "or":
-		asmC = "// or\n"
-		asmC += fmt.Sprintf(alu2ParamCommand, "M|D")
-	case "not":
-		asmC = "// not\n"
-		asmC += fmt.Sprintf(alu1ParamCommand, "!M")
-	default:
-		log.Fatal("arithmetic not implemented")
+// writeHackProgram translates the modules and assembles them straight into
+// the machine code file hackPath
+func writeHackProgram(hackPath string, modules []vmtranslator.Module, opts vmtranslator.Options) error {
+	var asm bytes.Buffer
+	if err := vmtranslator.Translate(modules, &asm, opts); err != nil {
+		return err
+	}
+	code, err := assembler.Assemble(&asm, hackPath)
+	if err != nil {
+		return err
 	}
-	c.writeCommand(asmC)
+	return writeHackFile(hackPath, code)
 }
 
-func (c *codeWriter) writePushPop(cmd command, segment string, index int) {
-	pushDToStack := "@SP\n" +
-		"A=M\n" +
-		"M=D\n" +
-		"@SP\n" +
-		"M=M+1\n"
-
-	pushSegmentToStack := "@%s\n" +
-		"D=M\n" +
-		"@%d\n" +
-		"A=A+D\n" +
-		"D=M\n" +
-		pushDToStack
-
-	pushRamToStack := "@%s\n" +
-		"D=A\n" +
-		"@%d\n" +
-		"A=A+D\n" +
-		"D=M\n" +
-		pushDToStack
-
-	popStackToD := "@SP\n" +
-		"M=M-1\n" +
-		"A=M\n" +
-		"D=M\n"
-
-	popStackToRam := "@%s\n" +
-		"D=A\n" +
-		"@%d\n" +
-		"D=A+D\n" +
-		"@R13\n" +
-		"M=D\n" +
-		popStackToD +
-		"@R13\n" +
-		"A=M\n" +
-		"M=D\n"
-
-	popStackToSegment := "@%s\n" +
-		"D=M\n" +
-		"@%d\n" +
-		"D=A+D\n" +
-		"@R13\n" +
-		"M=D\n" +
-		popStackToD +
-		"@R13\n" +
-		"A=M\n" +
-		"M=D\n"
+// reportAndExit prints the diagnostics in err and exits with status 1
+func reportAndExit(err error) {
+	var diagnostics vmtranslator.DiagnosticList
+	diagnostics.Add(err)
+	diagnostics.Report(os.Stderr)
+	os.Exit(1)
+}
 
-	switch cmd {
-	case C_PUSH:
-		switch segment {
-		case "constant":
-			cmd := fmt.Sprintf("// push constant %d\n", index)
-			cmd += fmt.Sprintf("@%d\n", index) +
-				"D=A\n" +
-				pushDToStack
-			c.writeCommand(cmd)
-		case "local":
-			cmd := fmt.Sprintf("// push local %d\n", index)
-			cmd += fmt.Sprintf(pushSegmentToStack, "LCL", index)
-			c.writeCommand(cmd)
-		case "argument":
-			cmd := fmt.Sprintf("// push argument %d\n", index)
-			cmd += fmt.Sprintf(pushSegmentToStack, "ARG", index)
-			c.writeCommand(cmd)
-		case "this":
-			cmd := fmt.Sprintf("// push this %d\n", index)
-			cmd += fmt.Sprintf(pushSegmentToStack, "THIS", index)
-			c.writeCommand(cmd)
-		case "that":
-			cmd := fmt.Sprintf("// push that %d\n", index)
-			cmd += fmt.Sprintf(pushSegmentToStack, "THAT", index)
-			c.writeCommand(cmd)
-		case "pointer":
-			cmd := fmt.Sprintf("// push pointer %d\n", index)
-			cmd += fmt.Sprintf(pushRamToStack, "THIS", index)
-			c.writeCommand(cmd)
-		case "temp":
-			cmd := fmt.Sprintf("// push temp %d\n", index)
-			cmd += fmt.Sprintf(pushRamToStack, "R5", index)
-			c.writeCommand(cmd)
-		case "static":
-			cmd := fmt.Sprintf("// push static %d\n", index)
-			cmd += fmt.Sprintf("@static.%s.%d\n", c.vmFileName, index)
-			cmd += "D=M\n"
-			cmd += pushDToStack
-			c.writeCommand(cmd)
-		default:
-			log.Fatal("push segment not implemented")
+// programPaths returns the .vm files of a program given as a single .vm file
+// or a directory, and the path of the .asm file it is translated to
+func programPaths(filePath string) ([]string, string, error) {
+	var paths []string
+	var asmPath string
+	// if filename has .vm extension, then it's a single file
+	if len(filePath) > 3 && filePath[len(filePath)-3:] == ".vm" {
+		fileName := filePath[:len(filePath)-3]
+		asmPath = fileName + ".asm"
+		paths = append(paths, filePath)
+	} else {
+		filePath = strings.TrimSuffix(filePath, "/")
+		fileName := strings.Split(filePath, "/")[len(strings.Split(filePath, "/"))-1]
+		asmPath = filePath + "/" + fileName + ".asm"
+		files, err := ioutil.ReadDir(filePath)
+		if err != nil {
+			return nil, "", err
 		}
-	case C_POP:
-		switch segment {
-		case "local":
-			cmd := fmt.Sprintf("// pop local %d\n", index)
-			cmd += fmt.Sprintf(popStackToSegment, "LCL", index)
-			c.writeCommand(cmd)
-		case "argument":
-			cmd := fmt.Sprintf("// pop argument %d\n", index)
-			cmd += fmt.Sprintf(popStackToSegment, "ARG", index)
-			c.writeCommand(cmd)
-		case "this":
-			cmd := fmt.Sprintf("// pop this %d\n", index)
-			cmd += fmt.Sprintf(popStackToSegment, "THIS", index)
-			c.writeCommand(cmd)
-		case "that":
-			cmd := fmt.Sprintf("// pop that %d\n", index)
-			cmd += fmt.Sprintf(popStackToSegment, "THAT", index)
-			c.writeCommand(cmd)
-		case "pointer":
-			cmd := fmt.Sprintf("// pop pointer %d\n", index)
-			cmd += fmt.Sprintf(popStackToRam, "THIS", index)
-			c.writeCommand(cmd)
-		case "temp":
-			cmd := fmt.Sprintf("// pop temp %d\n", index)
-			cmd += fmt.Sprintf(popStackToRam, "R5", index)
-			c.writeCommand(cmd)
-		case "static":
-			cmd := fmt.Sprintf("// pop static %d\n", index)
-			cmd += popStackToD
-			cmd += fmt.Sprintf("@static.%s.%d\n", c.vmFileName, index)
-			cmd += "M=D\n"
-			c.writeCommand(cmd)
-		default:
-			log.Fatal("pop segment not implemented")
+		for _, file := range files {
+			if !file.IsDir() && strings.HasSuffix(file.Name(), ".vm") {
+				paths = append(paths, filePath+"/"+file.Name())
+			}
 		}
-	default:
-		log.Fatal("push-pop not implemented")
 	}
+	return paths, asmPath, nil
 }
 
-func (c *codeWriter) writeLabel(label string) {
-	c.writeCommand(fmt.Sprintf("(%s)\n", label))
-}
-
-func (c *codeWriter) writeGoto(label string) {
-	gotoCmd := "@%s\n" +
-		"0;JMP\n"
-	c.writeCommand(fmt.Sprintf("// goto %s\n", label))
-	c.writeCommand(fmt.Sprintf(gotoCmd, label))
-}
-
-func (c *codeWriter) writeIf(label string) {
-	popStackToD := "@SP\n" +
-		"M=M-1\n" +
-		"A=M\n" +
-		"D=M\n"
-	ifGoto := "@%s\n" +
-		"D;JNE\n"
-	c.writeCommand(fmt.Sprintf("// if-goto %s\n", label))
-	c.writeCommand(fmt.Sprintf(popStackToD+ifGoto, label))
-}
-
-func (c *codeWriter) writeCall(functionName string, numArgs int) {
-	pushDToStack := "@SP\n" +
-		"A=M\n" +
-		"M=D\n" +
-		"@SP\n" +
-		"M=M+1\n"
-	returnAddress := fmt.Sprintf("%s:%d:%d", functionName, numArgs, c.cmdCount)
-	c.writeCommand(fmt.Sprintf("// ** start call %s %d **\n", functionName, numArgs))
-	c.writeCommand("// push return-address\n")
-	pushRetAddr := "@%s\n" +
-		"D=A\n" +
-		pushDToStack
-	c.writeCommand(fmt.Sprintf(pushRetAddr, returnAddress))
-	pushPointerToStack := "@%s\n" +
-		"D=M\n" +
-		pushDToStack
-	c.writeCommand("// push LCL\n")
-	c.writeCommand(fmt.Sprintf(pushPointerToStack, "LCL"))
-	c.writeCommand("// push ARG\n")
-	c.writeCommand(fmt.Sprintf(pushPointerToStack, "ARG"))
-	c.writeCommand("// push THIS\n")
-	c.writeCommand(fmt.Sprintf(pushPointerToStack, "THIS"))
-	c.writeCommand("// push THAT\n")
-	c.writeCommand(fmt.Sprintf(pushPointerToStack, "THAT"))
-	setARG := "@SP\n" +
-		"D=M\n" +
-		"@5\n" +
-		"D=D-A\n" +
-		"@%d\n" +
-		"D=D-A\n" +
-		"@ARG\n" +
-		"M=D\n"
-	c.writeCommand("// ARG = SP - n - 5\n")
-	c.writeCommand(fmt.Sprintf(setARG, numArgs))
-	// LCL = SP
-	setLCL := "@SP\n" +
-		"D=M\n" +
-		"@LCL\n" +
-		"M=D\n"
-	c.writeCommand("// LCL = SP\n")
-	c.writeCommand(setLCL)
-	c.writeCommand("// goto f\n")
-	c.writeGoto(functionName)
-	c.writeCommand("// label return-address\n")
-	c.writeLabel(returnAddress)
-	c.writeCommand(fmt.Sprintf("// ** end call %s %d **\n", functionName, numArgs))
-}
-
-func (c *codeWriter) writeReturn() {
-	c.writeCommand("// ** start return **\n")
-	frame := "@LCL\n" +
-		"D=M\n" +
-		"@R13\n" +
-		"M=D\n"
-	c.writeCommand("// FRAME = LCL\n")
-	c.writeCommand(frame)
-
-	ret := "@5\n" +
-		"A=D-A\n" +
-		"D=M\n" +
-		"@R14\n" +
-		"M=D\n"
-	c.writeCommand("// RET = *(FRAME - 5)\n")
-	c.writeCommand(ret)
-
-	popStackToD := "@SP\n" +
-		"M=M-1\n" +
-		"A=M\n" +
-		"D=M\n"
-	argPop := popStackToD +
-		"@ARG\n" +
-		"A=M\n" +
-		"M=D\n"
-	c.writeCommand("// *ARG = pop()\n")
-	c.writeCommand(argPop)
-
-	restoreSP := "@ARG\n" +
-		"A=M\n" +
-		"D=A+1\n" +
-		"@SP\n" +
-		"M=D\n"
-	c.writeCommand("// SP = ARG + 1\n")
-	c.writeCommand(restoreSP)
-
-	c.writeCommand("// THAT THIS ARG LCL\n")
-	frame1 := "@R13\n" +
-		"M=M-1\n" +
-		"A=M\n" +
-		"D=M\n"
-	that := frame1 +
-		"@THAT\n" +
-		"M=D\n"
-	c.writeCommand(that)
-	this := frame1 +
-		"@THIS\n" +
-		"M=D\n"
-	c.writeCommand(this)
-	arg := frame1 +
-		"@ARG\n" +
-		"M=D\n"
-	c.writeCommand(arg)
-	lcl := frame1 +
-		"@LCL\n" +
-		"M=D\n"
-	c.writeCommand(lcl)
-	gotoRET := "@R14\n" +
-		"A=M\n" +
-		"0;JMP\n"
-
-	c.writeCommand("// goto RET\n")
-	c.writeCommand(gotoRET)
-	c.writeCommand("// ** end return **\n")
+// parseProgram parses every file of a program, if some lines can't be
+// parsed the rest of the program is still validated so all errors are
+// reported together
+func parseProgram(paths []string) ([]vmtranslator.Module, error) {
+	var diagnostics vmtranslator.DiagnosticList
+	var modules []vmtranslator.Module
+	for _, path := range paths {
+		module, err := parseFile(path)
+		diagnostics.Add(err)
+		modules = append(modules, module)
+	}
+	if len(diagnostics) > 0 {
+		diagnostics.Add(vmtranslator.Validate(modules))
+	}
+	return modules, diagnostics.Err()
 }
 
-func (c *codeWriter) writeFunction(functionName string, numLocals int) {
-	c.writeCommand(fmt.Sprintf("// function %s %d\n", functionName, numLocals))
-	c.writeLabel(functionName)
-	for i := 0; i < numLocals; i++ {
-		c.writePushPop(C_PUSH, "constant", 0)
+// translateProgram translates the program at path and returns the assembly
+func translateProgram(path string, opts vmtranslator.Options) ([]byte, error) {
+	paths, _, err := programPaths(path)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := parseProgram(paths)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := vmtranslator.Translate(modules, &buf, opts); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
 }
 
-func (c *codeWriter) close() {
-	c.file.Close()
+// parseFile reads the vm file at path into a module named after the file
+func parseFile(path string) (vmtranslator.Module, error) {
+	name := path[strings.LastIndex(path, "/")+1:]
+	module := vmtranslator.Module{Name: strings.TrimSuffix(name, ".vm")}
+	file, err := os.Open(path)
+	if err != nil {
+		return module, err
+	}
+	defer file.Close()
+	module.Commands, err = vmtranslator.Parse(file, path)
+	return module, err
 }
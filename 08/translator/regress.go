@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// runRegress runs the tests of the module with go test, they diff the
+// translations of the project 07 and 08 programs against the golden files
+// and run the test scripts and checks. With -update only the golden files
+// are rewritten.
+func runRegress(args []string) {
+	flags := flag.NewFlagSet("regress", flag.ExitOnError)
+	update := flags.Bool("update", false, "rewrite the golden files with the current output")
+	verbose := flags.Bool("v", false, "list every test")
+	flags.Parse(args)
+
+	goArgs := []string{"test", "./..."}
+	if *update {
+		goArgs = []string{"test", ".", "-run", "^TestGolden$", "-update"}
+	}
+	if *verbose {
+		goArgs = append(goArgs, "-v")
+	}
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		log.Fatal(err)
+	}
+}
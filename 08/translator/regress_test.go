@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"translator/vmtranslator"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// root is the repository root the test programs are relative to
+const root = "../.."
+
+// regressionPrograms are the sample programs of projects 07 and 08, dir is
+// relative to the repository root. Their translation is compared with the
+// golden file golden/<dir>.asm.
+var regressionPrograms = []struct {
+	dir     string
+	profile vmtranslator.Profile
+}{
+	{"07/StackArithmetic/SimpleAdd", vmtranslator.ProfileStage1},
+	{"07/StackArithmetic/StackTest", vmtranslator.ProfileStage1},
+	{"07/MemoryAccess/BasicTest", vmtranslator.ProfileStage1},
+	{"07/MemoryAccess/PointerTest", vmtranslator.ProfileStage1},
+	{"07/MemoryAccess/StaticTest", vmtranslator.ProfileStage1},
+	{"08/ProgramFlow/BasicLoop", vmtranslator.ProfileFull},
+	{"08/ProgramFlow/FibonacciSeries", vmtranslator.ProfileFull},
+	{"08/FunctionCalls/SimpleFunction", vmtranslator.ProfileFull},
+	{"08/FunctionCalls/NestedCall", vmtranslator.ProfileFull},
+	{"08/FunctionCalls/FibonacciElement", vmtranslator.ProfileFull},
+	{"08/FunctionCalls/StaticsTest", vmtranslator.ProfileFull},
+}
+
+// regressionModes are the option sets the regression programs' test scripts
+// and the comparison checks are run with on the emulator
+var regressionModes = []struct {
+	name string
+	opts vmtranslator.Options
+}{
+	{"default", vmtranslator.Options{}},
+	{"-O", vmtranslator.Options{Optimize: true}},
+	{"-shared-routines", vmtranslator.Options{SharedRoutines: true}},
+	{"-shared-routines -O", vmtranslator.Options{SharedRoutines: true, Optimize: true}},
+	{"-Ovm", vmtranslator.Options{OptimizeVM: true}},
+	{"-Ovm -O -shared-routines -remove-dead", vmtranslator.Options{OptimizeVM: true, Optimize: true,
+		SharedRoutines: true, RemoveDeadFunctions: true}},
+	{"-safe-compare=false", vmtranslator.Options{FastCompare: true}},
+	{"-safe-compare=false -shared-routines", vmtranslator.Options{FastCompare: true, SharedRoutines: true}},
+}
+
+// TestGolden translates every regression program and diffs the output
+// against its golden file, with -update the golden files are rewritten
+func TestGolden(t *testing.T) {
+	for _, program := range regressionPrograms {
+		t.Run(program.dir, func(t *testing.T) {
+			goldenPath := filepath.Join("golden", program.dir+".asm")
+			opts := vmtranslator.Options{Profile: program.profile}
+			got, err := translateProgram(filepath.Join(root, program.dir), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("updated %s", goldenPath)
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				var diff strings.Builder
+				writeDiff(&diff, goldenPath, program.dir, string(want), string(got))
+				t.Errorf("translation differs from the golden file, run with -update if the change is intended\n%s", diff.String())
+			}
+		})
+	}
+}
+
+// TestVMScripts runs the VM emulator script of every regression program on
+// the vm interpreter, without and with OptimizeVM
+func TestVMScripts(t *testing.T) {
+	for _, opts := range []vmtranslator.Options{{}, {OptimizeVM: true}} {
+		name := "default"
+		if opts.OptimizeVM {
+			name = "-Ovm"
+		}
+		t.Run(name, func(t *testing.T) {
+			for _, program := range regressionPrograms {
+				t.Run(program.dir, func(t *testing.T) {
+					result, err := testVMProgram(filepath.Join(root, program.dir), t.TempDir(), opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !result.Passed() {
+						t.Error(result)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestScripts runs the CPU emulator script of every regression program on
+// the emulator in every regression mode
+func TestScripts(t *testing.T) {
+	for _, mode := range regressionModes {
+		t.Run(mode.name, func(t *testing.T) {
+			for _, program := range regressionPrograms {
+				t.Run(program.dir, func(t *testing.T) {
+					opts := mode.opts
+					opts.Profile = program.profile
+					result, err := testProgram(filepath.Join(root, program.dir), t.TempDir(), opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !result.Passed() {
+						t.Error(result)
+					}
+				})
+			}
+		})
+	}
+}
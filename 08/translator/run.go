@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"translator/assembler"
+	"translator/emulator"
+	"translator/vmemulator"
+	"translator/vmtranslator"
+)
+
+// runRun translates a program, executes it on the emulator and prints the
+// requested RAM cells
+func runRun(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	translateOptions := translateFlags(flags)
+	cycles := flags.Int("cycles", 1000000, "maximum number of instructions to execute")
+	set := flags.String("set", "", "RAM cells to set before running, e.g. 0=256,1=300")
+	interpret := flags.Bool("interpret", false,
+		"run the vm commands on the vm interpreter instead of translating them, -cycles counts vm commands")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatal("usage: translator run [-interpret] [-cycles n] [-set addr=value,...] <file.vm|dir> [addr|from-to ...]")
+	}
+
+	opts := translateOptions()
+	var computer machine
+	var err error
+	if *interpret {
+		computer, err = loadInterpreter(flags.Arg(0), opts)
+	} else {
+		var c *emulator.Computer
+		c, err = loadProgram(flags.Arg(0), opts)
+		computer = computerMachine{c}
+	}
+	if err != nil {
+		reportAndExit(err)
+	}
+	if *set != "" {
+		for _, assignment := range strings.Split(*set, ",") {
+			address, value, ok := strings.Cut(assignment, "=")
+			if !ok {
+				log.Fatalf("bad -set value: %s", assignment)
+			}
+			a, err := ramAddress(address)
+			if err != nil {
+				log.Fatal(err)
+			}
+			v, err := strconv.ParseInt(value, 10, 16)
+			if err != nil {
+				log.Fatalf("bad -set value: %s", assignment)
+			}
+			computer.Poke(a, int16(v))
+		}
+	}
+
+	err = computer.Run(*cycles)
+	count, unit := computer.Count()
+	if err == emulator.ErrCycleLimit || err == vmemulator.ErrStepLimit {
+		fmt.Printf("still running after %d %s\n", count, unit)
+	} else if err != nil {
+		log.Fatal(err)
+	} else {
+		fmt.Printf("halted after %d %s\n", count, unit)
+	}
+
+	for _, cells := range flags.Args()[1:] {
+		from, to, isRange := strings.Cut(cells, "-")
+		first, err := ramAddress(from)
+		if err != nil {
+			log.Fatal(err)
+		}
+		last := first
+		if isRange {
+			if last, err = ramAddress(to); err != nil {
+				log.Fatal(err)
+			}
+		}
+		for a := first; a <= last; a++ {
+			fmt.Printf("RAM[%d] = %d\n", a, computer.Peek(a))
+		}
+	}
+}
+
+// machine is what run needs from the Hack computer and the vm interpreter
+type machine interface {
+	Run(max int) error
+	Peek(address int) int16
+	Poke(address int, value int16)
+	// Count returns how many cycles or vm commands were executed
+	Count() (int, string)
+}
+
+type computerMachine struct{ *emulator.Computer }
+
+func (c computerMachine) Count() (int, string) {
+	return c.Cycles, "cycles"
+}
+
+type interpreterMachine struct{ *vmemulator.VM }
+
+func (vm interpreterMachine) Count() (int, string) {
+	return vm.Steps, "vm commands"
+}
+
+// loadInterpreter parses the program at path into a vm interpreter, with
+// the bootstrap it starts with a call to Sys.init like the translated code
+func loadInterpreter(path string, opts vmtranslator.Options) (machine, error) {
+	paths, _, err := programPaths(path)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := parseProgram(paths)
+	if err != nil {
+		return nil, err
+	}
+	if err := vmtranslator.Validate(modules); err != nil {
+		return nil, err
+	}
+	if opts.OptimizeVM {
+		modules = vmtranslator.OptimizeVM(modules)
+	}
+	vm, err := vmemulator.New(modules)
+	if err != nil {
+		return nil, err
+	}
+	bootstrap := opts.Bootstrap == vmtranslator.BootstrapAlways ||
+		(opts.Bootstrap != vmtranslator.BootstrapNever && vm.Defines("Sys.init"))
+	if bootstrap && opts.Profile != vmtranslator.ProfileStage1 {
+		if err := vm.Bootstrap("Sys.init"); err != nil {
+			return nil, err
+		}
+	}
+	return interpreterMachine{vm}, nil
+}
+
+// loadProgram translates and assembles the program at path and loads it
+// into a new computer
+func loadProgram(path string, opts vmtranslator.Options) (*emulator.Computer, error) {
+	asm, err := translateProgram(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	code, err := assembler.Assemble(bytes.NewReader(asm), path)
+	if err != nil {
+		return nil, err
+	}
+	return emulator.New(code)
+}
+
+func ramAddress(s string) (int, error) {
+	a, err := strconv.Atoi(s)
+	if err != nil || a < 0 || a >= emulator.RAMSize {
+		return 0, fmt.Errorf("bad RAM address: %s", s)
+	}
+	return a, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"translator/tst"
+	"translator/vmtranslator"
+)
+
+// runTest translates a program and runs its test script on the emulator,
+// like loading the .tst file in the official CPU emulator. With -vm the
+// VM emulator script runs on the vm interpreter instead.
+func runTest(args []string) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	translateOptions := translateFlags(flags)
+	vm := flags.Bool("vm", false, "run the <dir>VME.tst script of a program directory on the vm interpreter")
+	out := flags.String("out", "", "directory to write the output file to, default the script's directory")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatal("usage: translator test [-vm] [-out dir] <dir|file.tst>")
+	}
+
+	opts := translateOptions()
+	var result *tst.Result
+	var err error
+	if *vm {
+		result, err = testVMProgram(flags.Arg(0), *out, opts)
+	} else {
+		result, err = testProgram(flags.Arg(0), *out, opts)
+	}
+	if err != nil {
+		reportAndExit(err)
+	}
+	fmt.Println(result)
+	if !result.Passed() {
+		os.Exit(1)
+	}
+}
+
+// testProgram runs the test script at path, or the <dir>.tst script if path
+// is a program directory. CPU emulator scripts get the program in the
+// script's directory translated in memory in place of its .asm file, VM
+// emulator scripts run on the vm interpreter. The output file is written to
+// outDir, or next to the script if outDir is empty.
+func testProgram(path, outDir string, opts vmtranslator.Options) (*tst.Result, error) {
+	return runScript(scriptPath(path, ".tst"), outDir, opts)
+}
+
+// testVMProgram runs the VM emulator script at path, or the <dir>VME.tst
+// script if path is a program directory, of opts only OptimizeVM applies
+func testVMProgram(path, outDir string, opts vmtranslator.Options) (*tst.Result, error) {
+	return runScript(scriptPath(path, "VME.tst"), outDir, opts)
+}
+
+// scriptPath returns path if it is a .tst file, or the script
+// <dir>/<dir><suffix> of the program directory path
+func scriptPath(path, suffix string) string {
+	path = strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(path, ".tst") {
+		return path
+	}
+	return filepath.Join(path, filepath.Base(path)+suffix)
+}
+
+func runScript(scriptPath, outDir string, opts vmtranslator.Options) (*tst.Result, error) {
+	dir := filepath.Dir(scriptPath)
+	if outDir == "" {
+		outDir = dir
+	}
+	file, err := os.Open(scriptPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	script, err := tst.Parse(file, scriptPath)
+	if err != nil {
+		return nil, err
+	}
+	if script.Flavor() == tst.FlavorVM {
+		return tst.Run(script, dir, outDir, &tst.VM{Dir: dir, Options: opts})
+	}
+
+	asm, err := translateProgram(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	cpu := &tst.CPU{
+		Dir:   dir,
+		Files: map[string][]byte{filepath.Base(dir) + ".asm": asm},
+	}
+	return tst.Run(script, dir, outDir, cpu)
+}
@@ -0,0 +1,145 @@
+package tst
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"translator/assembler"
+	"translator/emulator"
+)
+
+// CPU runs CPU emulator scripts on the Hack computer of the emulator
+// package
+type CPU struct {
+	// Dir is the directory load looks for files in
+	Dir string
+	// Files are loaded instead of the files with the same name in Dir, so a
+	// program can be tested without writing its .asm file first
+	Files    map[string][]byte
+	computer *emulator.Computer
+}
+
+// Load assembles an .asm file or reads a .hack file into a new computer
+func (c *CPU) Load(file string) error {
+	if file == "" {
+		return fmt.Errorf("load needs an .asm or .hack file")
+	}
+	src, ok := c.Files[file]
+	if !ok {
+		var err error
+		src, err = os.ReadFile(filepath.Join(c.Dir, file))
+		if err != nil {
+			return err
+		}
+	}
+	var code []uint16
+	var err error
+	if strings.HasSuffix(file, ".hack") {
+		code, err = readHack(src)
+	} else {
+		code, err = assembler.Assemble(bytes.NewReader(src), file)
+	}
+	if err != nil {
+		return err
+	}
+	c.computer, err = emulator.New(code)
+	return err
+}
+
+// readHack reads machine code written as lines of 16 binary digits
+func readHack(src []byte) ([]uint16, error) {
+	var code []uint16
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		word, err := strconv.ParseUint(line, 2, 16)
+		if err != nil || len(line) != 16 {
+			return nil, fmt.Errorf("bad machine code %q", line)
+		}
+		code = append(code, uint16(word))
+	}
+	return code, scanner.Err()
+}
+
+// Computer returns the loaded computer, nil before load
+func (c *CPU) Computer() *emulator.Computer {
+	return c.computer
+}
+
+func (c *CPU) Get(variable string) (int16, error) {
+	if c.computer == nil {
+		return 0, fmt.Errorf("no program loaded")
+	}
+	switch variable {
+	case "PC":
+		return int16(c.computer.PC), nil
+	case "A":
+		return int16(c.computer.A), nil
+	case "D":
+		return int16(c.computer.D), nil
+	case "time":
+		return int16(c.computer.Cycles), nil
+	}
+	address, err := ramVariable(variable)
+	if err != nil {
+		return 0, err
+	}
+	return c.computer.Peek(address), nil
+}
+
+func (c *CPU) Set(variable string, value int16) error {
+	if c.computer == nil {
+		return fmt.Errorf("no program loaded")
+	}
+	switch variable {
+	case "PC":
+		c.computer.PC = uint16(value)
+		return nil
+	case "A":
+		c.computer.A = uint16(value)
+		return nil
+	case "D":
+		c.computer.D = uint16(value)
+		return nil
+	}
+	address, err := ramVariable(variable)
+	if err != nil {
+		return err
+	}
+	c.computer.Poke(address, value)
+	return nil
+}
+
+// Step executes an instruction on tock and ticktock, tick alone does
+// nothing as the CPU state only changes at the end of the clock cycle
+func (c *CPU) Step(command string) error {
+	if c.computer == nil {
+		return fmt.Errorf("no program loaded")
+	}
+	switch command {
+	case "tick":
+		return nil
+	case "tock", "ticktock":
+		return c.computer.Step()
+	}
+	return fmt.Errorf("%s is not supported by the CPU emulator", command)
+}
+
+// ramVariable returns the address of RAM[address]
+func ramVariable(variable string) (int, error) {
+	if strings.HasPrefix(variable, "RAM[") && strings.HasSuffix(variable, "]") {
+		address, err := strconv.Atoi(variable[4 : len(variable)-1])
+		if err == nil && address >= 0 && address < emulator.RAMSize {
+			return address, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variable %s", variable)
+}
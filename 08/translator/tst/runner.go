@@ -0,0 +1,368 @@
+package tst
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MaxIterations stops a repeat without a count or a while that is still
+// running, the official emulators run them until they are stopped by hand
+const MaxIterations = 10000000
+
+// Machine is the emulator a script runs on
+type Machine interface {
+	// Load loads the program named by the load command, file is empty for
+	// `load` without arguments
+	Load(file string) error
+	// Get and Set access variables such as RAM[256], PC or sp
+	Get(variable string) (int16, error)
+	Set(variable string, value int16) error
+	// Step executes a clock command: tick, tock, ticktock or vmstep
+	Step(command string) error
+}
+
+// Result is the outcome of running a script
+type Result struct {
+	// Output is the table written by the output commands
+	Output string
+	// OutputFile and CompareTo are the files named by the script, relative
+	// to its directory
+	OutputFile string
+	CompareTo  string
+	// FailureLine is the first line of Output that doesn't match the
+	// compare file, 0 if all lines match
+	FailureLine int
+	Expected    string
+	Got         string
+}
+
+// Passed reports whether the output matched the compare file
+func (r *Result) Passed() bool {
+	return r.FailureLine == 0
+}
+
+func (r *Result) String() string {
+	if r.Passed() {
+		return "End of script - Comparison ended successfully"
+	}
+	return fmt.Sprintf("Comparison failure at line %d\nexpected: %s\ngot:      %s",
+		r.FailureLine, r.Expected, r.Got)
+}
+
+// column of the output-list command, e.g. RAM[0]%D2.6.2
+type column struct {
+	variable string
+	format   byte
+	left     int
+	width    int
+	right    int
+}
+
+type runner struct {
+	script  *Script
+	dir     string
+	machine Machine
+	result  Result
+	columns []column
+	out     strings.Builder
+	lines   int
+	compare []string
+}
+
+// Run executes the script on machine. Files named by the script are
+// relative to dir, the output is compared with the compare file after every
+// output command like the official emulators do. The output file is written
+// to outDir, or not at all if outDir is empty, so the callers decide
+// whether the program's directory gets a new .out file.
+func Run(script *Script, dir, outDir string, machine Machine) (*Result, error) {
+	r := &runner{script: script, dir: dir, machine: machine}
+	if err := r.run(script.Commands); err != nil {
+		return nil, err
+	}
+	r.result.Output = r.out.String()
+	if r.result.OutputFile != "" && outDir != "" {
+		path := filepath.Join(outDir, r.result.OutputFile)
+		if err := os.WriteFile(path, []byte(r.result.Output), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return &r.result, nil
+}
+
+func (r *runner) errorf(c Command, format string, args ...interface{}) error {
+	return &Error{r.script.Name, c.Line, fmt.Sprintf(format, args...)}
+}
+
+func (r *runner) run(commands []Command) error {
+	for _, c := range commands {
+		if err := r.exec(c); err != nil {
+			return err
+		}
+		if !r.result.Passed() {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *runner) exec(c Command) error {
+	switch c.Name {
+	case "load":
+		file := ""
+		if len(c.Args) > 0 {
+			file = c.Args[0]
+		}
+		if err := r.machine.Load(file); err != nil {
+			return r.errorf(c, "%s", err)
+		}
+	case "output-file":
+		if len(c.Args) != 1 {
+			return r.errorf(c, "output-file needs a file name")
+		}
+		r.result.OutputFile = c.Args[0]
+	case "compare-to":
+		if len(c.Args) != 1 {
+			return r.errorf(c, "compare-to needs a file name")
+		}
+		r.result.CompareTo = c.Args[0]
+		data, err := os.ReadFile(filepath.Join(r.dir, c.Args[0]))
+		if err != nil {
+			return r.errorf(c, "%s", err)
+		}
+		r.compare = strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\n")
+	case "output-list":
+		r.columns = nil
+		for _, arg := range c.Args {
+			col, err := parseColumn(arg)
+			if err != nil {
+				return r.errorf(c, "%s", err)
+			}
+			r.columns = append(r.columns, col)
+		}
+		r.writeLine(r.header())
+	case "output":
+		line, err := r.values()
+		if err != nil {
+			return r.errorf(c, "%s", err)
+		}
+		r.writeLine(line)
+	case "set":
+		if len(c.Args) != 2 {
+			return r.errorf(c, "set needs a variable and a value")
+		}
+		value, err := parseValue(c.Args[1])
+		if err != nil {
+			return r.errorf(c, "%s", err)
+		}
+		if err := r.machine.Set(c.Args[0], value); err != nil {
+			return r.errorf(c, "%s", err)
+		}
+	case "tick", "tock", "ticktock", "vmstep":
+		if err := r.machine.Step(c.Name); err != nil {
+			return r.errorf(c, "%s", err)
+		}
+	case "repeat":
+		for i := 0; c.Count == 0 || i < c.Count; i++ {
+			if i == MaxIterations {
+				return r.errorf(c, "repeat without a count is still running after %d iterations", MaxIterations)
+			}
+			if err := r.run(c.Body); err != nil {
+				return err
+			}
+			if !r.result.Passed() {
+				return nil
+			}
+		}
+	case "while":
+		for i := 0; ; i++ {
+			if i == MaxIterations {
+				return r.errorf(c, "while is still running after %d iterations", MaxIterations)
+			}
+			ok, err := r.condition(c.Args)
+			if err != nil {
+				return r.errorf(c, "%s", err)
+			}
+			if !ok {
+				return nil
+			}
+			if err := r.run(c.Body); err != nil {
+				return err
+			}
+			if !r.result.Passed() {
+				return nil
+			}
+		}
+	case "echo", "clear-echo", "breakpoint", "clear-breakpoints":
+		// only meaningful in the GUI
+	default:
+		return r.errorf(c, "unknown command %s", c.Name)
+	}
+	return nil
+}
+
+// writeLine appends a line to the output and compares it with the same line
+// of the compare file, `*` in the compare file matches any character
+func (r *runner) writeLine(line string) {
+	r.out.WriteString(line + "\n")
+	r.lines++
+	if r.compare == nil {
+		return
+	}
+	n := r.lines
+	want := ""
+	if n <= len(r.compare) {
+		want = r.compare[n-1]
+	}
+	if !matchLine(want, line) {
+		r.result.FailureLine = n
+		r.result.Expected = want
+		r.result.Got = line
+	}
+}
+
+func matchLine(want, got string) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := 0; i < len(want); i++ {
+		if want[i] != '*' && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// condition evaluates `variable op value` of a while command
+func (r *runner) condition(args []string) (bool, error) {
+	x, err := r.machine.Get(args[0])
+	if err != nil {
+		return false, err
+	}
+	y, err := parseValue(args[2])
+	if err != nil {
+		return false, err
+	}
+	switch args[1] {
+	case "=":
+		return x == y, nil
+	case "<>":
+		return x != y, nil
+	case "<":
+		return x < y, nil
+	case "<=":
+		return x <= y, nil
+	case ">":
+		return x > y, nil
+	case ">=":
+		return x >= y, nil
+	}
+	return false, fmt.Errorf("unknown comparison %s", args[1])
+}
+
+// parseColumn parses variable%Fleft.width.right, without a format the
+// column is %D1.6.1
+func parseColumn(s string) (column, error) {
+	col := column{variable: s, format: 'D', left: 1, width: 6, right: 1}
+	i := strings.IndexByte(s, '%')
+	if i < 0 {
+		return col, nil
+	}
+	col.variable = s[:i]
+	spec := s[i+1:]
+	if spec == "" {
+		return col, fmt.Errorf("bad output format %q", s)
+	}
+	col.format = spec[0]
+	if !strings.ContainsRune("DXBS", rune(col.format)) {
+		return col, fmt.Errorf("bad output format %q", s)
+	}
+	parts := strings.Split(spec[1:], ".")
+	if len(parts) != 3 {
+		return col, fmt.Errorf("bad output format %q", s)
+	}
+	sizes := []*int{&col.left, &col.width, &col.right}
+	for j, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return col, fmt.Errorf("bad output format %q", s)
+		}
+		*sizes[j] = n
+	}
+	return col, nil
+}
+
+// header returns the column names centered in their columns, cut to the
+// column width if they are too long
+func (r *runner) header() string {
+	var b strings.Builder
+	b.WriteByte('|')
+	for _, col := range r.columns {
+		total := col.left + col.width + col.right
+		name := col.variable
+		if len(name) > total {
+			name = name[:total]
+		}
+		left := (total - len(name)) / 2
+		b.WriteString(strings.Repeat(" ", left))
+		b.WriteString(name)
+		b.WriteString(strings.Repeat(" ", total-left-len(name)))
+		b.WriteByte('|')
+	}
+	return b.String()
+}
+
+// values returns the current values of the columns
+func (r *runner) values() (string, error) {
+	var b strings.Builder
+	b.WriteByte('|')
+	for _, col := range r.columns {
+		value, err := r.machine.Get(col.variable)
+		if err != nil {
+			return "", err
+		}
+		var text string
+		switch col.format {
+		case 'D', 'S':
+			text = strconv.Itoa(int(value))
+		case 'X':
+			text = fmt.Sprintf("%04X", uint16(value))
+		case 'B':
+			text = fmt.Sprintf("%016b", uint16(value))
+		}
+		if len(text) > col.width {
+			text = text[len(text)-col.width:]
+		}
+		b.WriteString(strings.Repeat(" ", col.left))
+		b.WriteString(strings.Repeat(" ", col.width-len(text)))
+		b.WriteString(text)
+		b.WriteString(strings.Repeat(" ", col.right))
+		b.WriteByte('|')
+	}
+	return b.String(), nil
+}
+
+// parseValue parses a set value: a decimal number or a number prefixed with
+// %D, %X or %B
+func parseValue(s string) (int16, error) {
+	base := 10
+	if len(s) > 2 && s[0] == '%' {
+		switch s[1] {
+		case 'D':
+		case 'X':
+			base = 16
+		case 'B':
+			base = 2
+		default:
+			return 0, fmt.Errorf("bad value %q", s)
+		}
+		s = s[2:]
+	}
+	n, err := strconv.ParseInt(s, base, 32)
+	if err != nil || n < -32768 || n > 65535 {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return int16(n), nil
+}
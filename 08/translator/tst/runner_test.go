@@ -0,0 +1,189 @@
+package tst
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeMachine holds variables and counts the steps, every step increments
+// RAM[0]
+type fakeMachine struct {
+	vars  map[string]int16
+	steps int
+}
+
+func (m *fakeMachine) Load(file string) error { return nil }
+
+func (m *fakeMachine) Get(variable string) (int16, error) { return m.vars[variable], nil }
+
+func (m *fakeMachine) Set(variable string, value int16) error {
+	m.vars[variable] = value
+	return nil
+}
+
+func (m *fakeMachine) Step(command string) error {
+	m.steps++
+	m.vars["RAM[0]"]++
+	return nil
+}
+
+func TestParseColumn(t *testing.T) {
+	tests := []struct {
+		s    string
+		want column
+		err  bool
+	}{
+		{"RAM[0]", column{"RAM[0]", 'D', 1, 6, 1}, false},
+		{"RAM[3000]%D1.6.2", column{"RAM[3000]", 'D', 1, 6, 2}, false},
+		{"PC%X2.4.2", column{"PC", 'X', 2, 4, 2}, false},
+		{"A%B0.16.0", column{"A", 'B', 0, 16, 0}, false},
+		{"RAM[0]%", column{}, true},
+		{"RAM[0]%Q1.6.1", column{}, true},
+		{"RAM[0]%D1.6", column{}, true},
+		{"RAM[0]%D1.x.1", column{}, true},
+	}
+	for _, test := range tests {
+		got, err := parseColumn(test.s)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseColumn(%q): no error", test.s)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("parseColumn(%q) = %+v, %v, want %+v", test.s, got, err, test.want)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int16
+		err  bool
+	}{
+		{"256", 256, false},
+		{"-1", -1, false},
+		{"-32768", -32768, false},
+		{"65535", -1, false},
+		{"%D12", 12, false},
+		{"%XFFFF", -1, false},
+		{"%B101", 5, false},
+		{"65536", 0, true},
+		{"%Q1", 0, true},
+		{"x", 0, true},
+	}
+	for _, test := range tests {
+		got, err := parseValue(test.s)
+		if test.err != (err != nil) || got != test.want {
+			t.Errorf("parseValue(%q) = %d, %v, want %d", test.s, got, err, test.want)
+		}
+	}
+}
+
+// TestFormat checks the header and values against the compare file of
+// SimpleFunction
+func TestFormat(t *testing.T) {
+	r := &runner{machine: &fakeMachine{vars: map[string]int16{
+		"RAM[0]": 311, "RAM[1]": 305, "RAM[2]": 300, "RAM[3]": 3010, "RAM[4]": 4010, "RAM[310]": 1196,
+	}}}
+	for _, name := range []string{"RAM[0]", "RAM[1]", "RAM[2]", "RAM[3]", "RAM[4]", "RAM[310]"} {
+		col, err := parseColumn(name + "%D1.6.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.columns = append(r.columns, col)
+	}
+	if got, want := r.header(), "| RAM[0] | RAM[1] | RAM[2] | RAM[3] | RAM[4] |RAM[310]|"; got != want {
+		t.Errorf("header:\ngot  %s\nwant %s", got, want)
+	}
+	got, err := r.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "|    311 |    305 |    300 |   3010 |   4010 |   1196 |"; got != want {
+		t.Errorf("values:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	r := &runner{machine: &fakeMachine{vars: map[string]int16{"A": -2, "RAM[3000]": 5}}}
+	for _, spec := range []string{"A%X1.4.1", "A%B1.16.1", "A%D1.6.1", "RAM[3000]%D1.6.2"} {
+		col, err := parseColumn(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.columns = append(r.columns, col)
+	}
+	got, err := r.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "| FFFE | 1111111111111110 |     -2 |      5  |"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	cmp := "|RAM[0]|\r\n|     3|\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "X.cmp"), []byte(cmp), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := "load X.asm, output-file X.out, compare-to X.cmp, output-list RAM[0]%D0.6.0;\n" +
+		"set RAM[0] 1, repeat 2 { ticktock; } output;"
+	script, err := Parse(strings.NewReader(src), "X.tst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	result, err := Run(script, dir, out, &fakeMachine{vars: map[string]int16{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed() {
+		t.Error(result)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "X.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "|RAM[0]|\n|     3|\n"; string(data) != want {
+		t.Errorf("output file: got %q, want %q", data, want)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "X.cmp"), []byte("|RAM[0]|\n|     4|\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script, err := Parse(strings.NewReader("compare-to X.cmp, output-list RAM[0]%D0.6.0; tick; output;"), "X.tst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(script, dir, "", &fakeMachine{vars: map[string]int16{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FailureLine != 2 || result.Expected != "|     4|" || result.Got != "|     1|" {
+		t.Errorf("got %+v", result)
+	}
+}
+
+// TestRunForever checks that a repeat without a count ends with an error
+func TestRunForever(t *testing.T) {
+	for _, src := range []string{"repeat { tick; }", "set RAM[0] 1, while RAM[0] <> 0 { set RAM[0] 1; }"} {
+		script, err := Parse(strings.NewReader(src), "X.tst")
+		if err != nil {
+			t.Fatal(err)
+		}
+		machine := &fakeMachine{vars: map[string]int16{}}
+		_, err = Run(script, "", "", machine)
+		if err == nil || !strings.Contains(err.Error(), "still running after") {
+			t.Errorf("%s: got %v, want an error", src, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package tst runs the test scripts (.tst) of the nand2tetris CPU emulator
+// and VM emulator and compares their output with the .cmp files.
+package tst
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Command is a command of a test script. Repeat and while commands have a
+// body, Count is the number of repetitions of a repeat, 0 means until Run
+// gives up after MaxIterations.
+type Command struct {
+	Name  string
+	Args  []string
+	Count int
+	Body  []Command
+	Line  int
+}
+
+// Script is a parsed test script
+type Script struct {
+	Name     string
+	Commands []Command
+}
+
+// Flavor is the emulator a script was written for
+type Flavor string
+
+const (
+	FlavorCPU Flavor = "cpu"
+	FlavorVM  Flavor = "vm"
+)
+
+// Flavor tells CPU emulator scripts, which load an .asm or .hack file and
+// tick the clock, from VM emulator scripts, which load .vm files and step
+// through vm commands
+func (s *Script) Flavor() Flavor {
+	flavor := FlavorCPU
+	walk(s.Commands, func(c Command) {
+		if c.Name == "vmstep" {
+			flavor = FlavorVM
+		}
+		if c.Name == "load" && (len(c.Args) == 0 || strings.HasSuffix(c.Args[0], ".vm")) {
+			flavor = FlavorVM
+		}
+	})
+	return flavor
+}
+
+func walk(commands []Command, f func(Command)) {
+	for _, c := range commands {
+		f(c)
+		walk(c.Body, f)
+	}
+}
+
+// Error is a syntax error in a test script
+type Error struct {
+	Name string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Msg)
+}
+
+// Parse reads a test script from r, name is used in error messages
+func Parse(r io.Reader, name string) (*Script, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &scriptParser{name: name, tokens: lexScript(string(src))}
+	commands, err := p.commands(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{Name: name, Commands: commands}, nil
+}
+
+// scriptToken is a word of a script, terminators `,` `;` and braces are
+// tokens of their own
+type scriptToken struct {
+	text string
+	line int
+}
+
+// lexScript splits a script into tokens, dropping `//` and `/* */`
+// comments. Quoted strings are kept as one token with the quotes.
+func lexScript(src string) []scriptToken {
+	var tokens []scriptToken
+	line := 1
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == '\n':
+			line++
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i - 2
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case ch == ',' || ch == ';' || ch == '{' || ch == '}':
+			tokens = append(tokens, scriptToken{string(ch), line})
+			i++
+		case ch == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				end = len(src) - i - 1
+			}
+			tokens = append(tokens, scriptToken{src[i : i+end+2], line})
+			i += end + 2
+		default:
+			start := i
+			for i < len(src) && !strings.ContainsRune(" \t\r\n,;{}", rune(src[i])) &&
+				!strings.HasPrefix(src[i:], "//") {
+				i++
+			}
+			tokens = append(tokens, scriptToken{src[start:i], line})
+		}
+	}
+	return tokens
+}
+
+type scriptParser struct {
+	name   string
+	tokens []scriptToken
+	pos    int
+}
+
+func (p *scriptParser) errorf(line int, format string, args ...interface{}) error {
+	return &Error{p.name, line, fmt.Sprintf(format, args...)}
+}
+
+// commands parses commands until the end of the script, or until the
+// closing brace if inBlock is set
+func (p *scriptParser) commands(inBlock bool) ([]Command, error) {
+	var commands []Command
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		switch tok.text {
+		case "}":
+			if !inBlock {
+				return nil, p.errorf(tok.line, "unexpected }")
+			}
+			p.pos++
+			return commands, nil
+		case ",", ";":
+			p.pos++
+			continue
+		}
+		c, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, c)
+	}
+	if inBlock {
+		return nil, p.errorf(p.tokens[len(p.tokens)-1].line, "missing }")
+	}
+	return commands, nil
+}
+
+// command parses a command and its arguments up to the terminator or the
+// body of a repeat or while
+func (p *scriptParser) command() (Command, error) {
+	tok := p.tokens[p.pos]
+	p.pos++
+	c := Command{Name: tok.text, Line: tok.line}
+	for p.pos < len(p.tokens) {
+		text := p.tokens[p.pos].text
+		if text == "," || text == ";" || text == "}" {
+			break
+		}
+		p.pos++
+		if text == "{" {
+			if c.Name != "repeat" && c.Name != "while" {
+				return c, p.errorf(tok.line, "%s can't have a block", c.Name)
+			}
+			body, err := p.commands(true)
+			if err != nil {
+				return c, err
+			}
+			c.Body = body
+			break
+		}
+		c.Args = append(c.Args, text)
+	}
+	switch c.Name {
+	case "repeat":
+		if c.Body == nil {
+			return c, p.errorf(tok.line, "repeat needs a block")
+		}
+		if len(c.Args) > 0 {
+			n, err := strconv.Atoi(c.Args[0])
+			if err != nil || n < 0 {
+				return c, p.errorf(tok.line, "bad repeat count %q", c.Args[0])
+			}
+			c.Count = n
+		}
+	case "while":
+		if c.Body == nil || len(c.Args) != 3 {
+			return c, p.errorf(tok.line, "while needs a condition and a block")
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package tst
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLexScript(t *testing.T) {
+	src := "load Prog.asm,\r\n" +
+		"output-list RAM[0]%D2.6.2; // comment\n" +
+		"/* two\nlines */ repeat 3 {\n\tticktock;\n}\n" +
+		"echo \"a b\";"
+	want := []scriptToken{
+		{"load", 1}, {"Prog.asm", 1}, {",", 1},
+		{"output-list", 2}, {"RAM[0]%D2.6.2", 2}, {";", 2},
+		{"repeat", 4}, {"3", 4}, {"{", 4},
+		{"ticktock", 5}, {";", 5},
+		{"}", 6},
+		{"echo", 7}, {`"a b"`, 7}, {";", 7},
+	}
+	if got := lexScript(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := "load X.asm, set RAM[0] 256,\nrepeat 2 { ticktock; }\nwhile RAM[0] > 0 { tick, tock; }\nrepeat { vmstep; }"
+	script, err := Parse(strings.NewReader(src), "X.tst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Command{
+		{Name: "load", Args: []string{"X.asm"}, Line: 1},
+		{Name: "set", Args: []string{"RAM[0]", "256"}, Line: 1},
+		{Name: "repeat", Args: []string{"2"}, Count: 2, Body: []Command{{Name: "ticktock", Line: 2}}, Line: 2},
+		{Name: "while", Args: []string{"RAM[0]", ">", "0"},
+			Body: []Command{{Name: "tick", Line: 3}, {Name: "tock", Line: 3}}, Line: 3},
+		{Name: "repeat", Body: []Command{{Name: "vmstep", Line: 4}}, Line: 4},
+	}
+	if !reflect.DeepEqual(script.Commands, want) {
+		t.Errorf("got %+v\nwant %+v", script.Commands, want)
+	}
+	if script.Flavor() != FlavorVM {
+		t.Errorf("flavor: got %s, want %s", script.Flavor(), FlavorVM)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"repeat 3;", "X.tst:1: repeat needs a block"},
+		{"repeat x { tick; }", `X.tst:1: bad repeat count "x"`},
+		{"\nrepeat 3 { tick;", "X.tst:2: missing }"},
+		{"tick; }", "X.tst:1: unexpected }"},
+		{"set RAM[0] 1 { tick; }", "X.tst:1: set can't have a block"},
+		{"while RAM[0] { tick; }", "X.tst:1: while needs a condition and a block"},
+	}
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.src), "X.tst")
+		if err == nil || err.Error() != test.want {
+			t.Errorf("Parse(%q): got %v, want %s", test.src, err, test.want)
+		}
+	}
+}
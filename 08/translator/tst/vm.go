@@ -0,0 +1,163 @@
+package tst
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"translator/vmemulator"
+	"translator/vmtranslator"
+)
+
+// VM runs VM emulator scripts on the interpreter of the vmemulator package
+type VM struct {
+	// Dir is the directory load looks for .vm files in
+	Dir string
+	// Options.OptimizeVM simplifies the loaded modules like the translator
+	// does, the other options only change the generated assembly
+	Options vmtranslator.Options
+	vm      *vmemulator.VM
+}
+
+// Load parses a .vm file, or every .vm file in Dir if file is empty, into a
+// new interpreter. Programs with Sys.init start there without a call, like
+// in the VM emulator, the scripts set up the stack for it.
+func (v *VM) Load(file string) error {
+	var paths []string
+	if file == "" {
+		entries, err := os.ReadDir(v.Dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".vm") {
+				paths = append(paths, filepath.Join(v.Dir, entry.Name()))
+			}
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no .vm files in %s", v.Dir)
+		}
+	} else if strings.HasSuffix(file, ".vm") {
+		paths = []string{filepath.Join(v.Dir, file)}
+	} else {
+		return fmt.Errorf("load needs a .vm file or no argument")
+	}
+
+	var modules []vmtranslator.Module
+	var diagnostics vmtranslator.DiagnosticList
+	for _, path := range paths {
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		commands, err := vmtranslator.Parse(src, path)
+		src.Close()
+		diagnostics.Add(err)
+		name := strings.TrimSuffix(filepath.Base(path), ".vm")
+		modules = append(modules, vmtranslator.Module{Name: name, Commands: commands})
+	}
+	if err := diagnostics.Err(); err != nil {
+		return err
+	}
+	if err := vmtranslator.Validate(modules); err != nil {
+		return err
+	}
+	if v.Options.OptimizeVM {
+		modules = vmtranslator.OptimizeVM(modules)
+	}
+	vm, err := vmemulator.New(modules)
+	if err != nil {
+		return err
+	}
+	if vm.Defines("Sys.init") {
+		if err := vm.Start("Sys.init"); err != nil {
+			return err
+		}
+	}
+	v.vm = vm
+	return nil
+}
+
+// Interpreter returns the loaded interpreter, nil before load
+func (v *VM) Interpreter() *vmemulator.VM {
+	return v.vm
+}
+
+func (v *VM) Get(variable string) (int16, error) {
+	if v.vm == nil {
+		return 0, fmt.Errorf("no program loaded")
+	}
+	address, err := v.variableAddress(variable)
+	if err != nil {
+		return 0, err
+	}
+	return v.vm.Peek(address), nil
+}
+
+func (v *VM) Set(variable string, value int16) error {
+	if v.vm == nil {
+		return fmt.Errorf("no program loaded")
+	}
+	address, err := v.variableAddress(variable)
+	if err != nil {
+		return err
+	}
+	v.vm.Poke(address, value)
+	return nil
+}
+
+// vmPointers are the variables of the VM emulator that name a pointer
+var vmPointers = map[string]int{
+	"sp":       vmemulator.SP,
+	"local":    vmemulator.LCL,
+	"argument": vmemulator.ARG,
+	"this":     vmemulator.THIS,
+	"that":     vmemulator.THAT,
+}
+
+// variableAddress returns the RAM address of sp, local, argument, this,
+// that, RAM[i] or segment[i] for the segments local, argument, this, that
+// and temp
+func (v *VM) variableAddress(variable string) (int, error) {
+	if address, ok := vmPointers[variable]; ok {
+		return address, nil
+	}
+	name, index, ok := strings.Cut(strings.TrimSuffix(variable, "]"), "[")
+	i, err := strconv.Atoi(index)
+	if !ok || !strings.HasSuffix(variable, "]") || err != nil || i < 0 {
+		return 0, fmt.Errorf("unknown variable %s", variable)
+	}
+	address := i
+	switch name {
+	case "RAM":
+	case "temp":
+		address += vmemulator.Temp
+	default:
+		pointer, ok := vmPointers[name]
+		if !ok || name == "sp" {
+			return 0, fmt.Errorf("unknown variable %s", variable)
+		}
+		address += int(v.vm.Peek(pointer))
+	}
+	if address < 0 || address >= len(v.vm.RAM) {
+		return 0, fmt.Errorf("%s is outside of RAM", variable)
+	}
+	return address, nil
+}
+
+// Step executes a vm command on vmstep, a program that ran past its last
+// command stays there
+func (v *VM) Step(command string) error {
+	if v.vm == nil {
+		return fmt.Errorf("no program loaded")
+	}
+	if command != "vmstep" {
+		return fmt.Errorf("%s is not supported by the VM emulator", command)
+	}
+	if v.vm.PC >= len(v.vm.Commands) {
+		return nil
+	}
+	return v.vm.Step()
+}
@@ -0,0 +1,418 @@
+// Package vmemulator executes programs of the nand2tetris VM language
+// directly, like the official VM emulator. It uses the same RAM layout as
+// the code written by vmtranslator, so the two can be compared.
+package vmemulator
+
+import (
+	"errors"
+	"fmt"
+
+	"translator/emulator"
+	"translator/vmtranslator"
+)
+
+// RAM addresses of the pointers and segments
+const (
+	SP   = 0
+	LCL  = 1
+	ARG  = 2
+	THIS = 3
+	THAT = 4
+	Temp = 5
+	// Static is where the static variables start, they are allocated in
+	// the order they first appear in the program like the assembler does
+	// for the translated code
+	Static = 16
+	// StackBase is where the bootstrap code starts the stack
+	StackBase = 256
+)
+
+// ErrStepLimit is returned by Run when the program is still running after
+// the given number of steps
+var ErrStepLimit = errors.New("step limit reached")
+
+// VM runs the commands of a program one at a time. PC is the index of the
+// next command in Commands, return addresses on the stack are indexes too.
+type VM struct {
+	Commands []vmtranslator.Command
+	RAM      []int16
+	PC       int
+	Steps    int
+	// modules are the names of the modules of the commands
+	modules   []string
+	functions map[string]int
+	// labels are the indexes of the labels scoped as function$label, like
+	// the translator writes them
+	labels  map[string]int
+	statics map[string]int
+	// function is the function each command belongs to
+	function []string
+}
+
+// New returns a VM with the modules loaded, PC at their first command and
+// RAM cleared. The modules are expected to be valid, see
+// vmtranslator.Validate.
+func New(modules []vmtranslator.Module) (*VM, error) {
+	vm := &VM{
+		RAM:       make([]int16, emulator.RAMSize),
+		functions: map[string]int{},
+		labels:    map[string]int{},
+		statics:   map[string]int{},
+	}
+	for _, module := range modules {
+		function := ""
+		for _, c := range module.Commands {
+			i := len(vm.Commands)
+			switch c.Type {
+			case vmtranslator.C_FUNCTION:
+				function = c.Arg1
+				vm.functions[function] = i
+			case vmtranslator.C_LABEL:
+				vm.labels[scopedLabel(function, c.Arg1)] = i
+			case vmtranslator.C_PUSH, vmtranslator.C_POP:
+				if c.Arg1 == "static" {
+					name := staticName(module.Name, c.Arg2)
+					if _, ok := vm.statics[name]; !ok {
+						vm.statics[name] = Static + len(vm.statics)
+					}
+				}
+			}
+			vm.Commands = append(vm.Commands, c)
+			vm.modules = append(vm.modules, module.Name)
+			vm.function = append(vm.function, function)
+		}
+	}
+	for i, c := range vm.Commands {
+		switch c.Type {
+		case vmtranslator.C_GOTO, vmtranslator.C_IF, vmtranslator.C_IFNOT:
+			if _, ok := vm.labels[scopedLabel(vm.function[i], c.Arg1)]; !ok {
+				return nil, fmt.Errorf("%s: label is not defined: %q", c.Pos, c.Arg1)
+			}
+		case vmtranslator.C_CALL:
+			if _, ok := vm.functions[c.Arg1]; !ok {
+				return nil, fmt.Errorf("%s: function is not defined: %q", c.Pos, c.Arg1)
+			}
+		}
+	}
+	return vm, nil
+}
+
+func scopedLabel(function, label string) string {
+	if function == "" {
+		return label
+	}
+	return function + "$" + label
+}
+
+func staticName(module string, index int) string {
+	return fmt.Sprintf("%s.%d", module, index)
+}
+
+// StaticAddress returns the RAM address of static variable index of module
+func (vm *VM) StaticAddress(module string, index int) (int, bool) {
+	address, ok := vm.statics[staticName(module, index)]
+	return address, ok
+}
+
+// Bootstrap sets SP to StackBase and calls function, like the bootstrap code
+// the translator writes for Sys.init
+func (vm *VM) Bootstrap(function string) error {
+	vm.RAM[SP] = StackBase
+	return vm.call(function, 0, len(vm.Commands))
+}
+
+// Start sets PC to the first command of function without calling it, like
+// the VM emulator does with Sys.init when it loads a program
+func (vm *VM) Start(function string) error {
+	i, ok := vm.functions[function]
+	if !ok {
+		return fmt.Errorf("function is not defined: %q", function)
+	}
+	vm.PC = i
+	return nil
+}
+
+// Defines reports whether the program has a function called function
+func (vm *VM) Defines(function string) bool {
+	_, ok := vm.functions[function]
+	return ok
+}
+
+// Function returns the function the command at PC belongs to
+func (vm *VM) Function() string {
+	if vm.PC >= len(vm.Commands) {
+		return ""
+	}
+	return vm.function[vm.PC]
+}
+
+// Halted reports whether the program ran past its last command or is stuck
+// in a `label END; goto END` loop
+func (vm *VM) Halted() bool {
+	pc := vm.nextCommand(vm.PC)
+	if pc >= len(vm.Commands) {
+		return true
+	}
+	c := vm.Commands[pc]
+	return c.Type == vmtranslator.C_GOTO &&
+		vm.nextCommand(vm.labels[scopedLabel(vm.function[pc], c.Arg1)]) == pc
+}
+
+// nextCommand returns the index of the first command from i on that isn't
+// a label
+func (vm *VM) nextCommand(i int) int {
+	for i < len(vm.Commands) && vm.Commands[i].Type == vmtranslator.C_LABEL {
+		i++
+	}
+	return i
+}
+
+// Run executes commands until the program halts or maxSteps commands were
+// executed, in which case it returns ErrStepLimit
+func (vm *VM) Run(maxSteps int) error {
+	for i := 0; i < maxSteps; i++ {
+		if vm.Halted() {
+			return nil
+		}
+		if err := vm.Step(); err != nil {
+			return err
+		}
+	}
+	if vm.Halted() {
+		return nil
+	}
+	return ErrStepLimit
+}
+
+// Step executes the command at PC. Labels are skipped, they don't count
+// as a step like in the VM emulator.
+func (vm *VM) Step() error {
+	vm.PC = vm.nextCommand(vm.PC)
+	if vm.PC >= len(vm.Commands) {
+		return fmt.Errorf("PC %d is past the end of the program", vm.PC)
+	}
+	c := vm.Commands[vm.PC]
+	vm.PC++
+	vm.Steps++
+	if err := vm.execute(c); err != nil {
+		return fmt.Errorf("%s: %s: %w", c.Pos, c, err)
+	}
+	return nil
+}
+
+func (vm *VM) execute(c vmtranslator.Command) error {
+	switch c.Type {
+	case vmtranslator.C_ARITHMETIC:
+		return vm.arithmetic(c.Arg1)
+	case vmtranslator.C_PUSH:
+		if c.Arg1 == "constant" {
+			return vm.push(int16(c.Arg2))
+		}
+		address, err := vm.address(c.Arg1, c.Arg2)
+		if err != nil {
+			return err
+		}
+		v, err := vm.load(address)
+		if err != nil {
+			return err
+		}
+		return vm.push(v)
+	case vmtranslator.C_POP:
+		address, err := vm.address(c.Arg1, c.Arg2)
+		if err != nil {
+			return err
+		}
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.store(address, v)
+	case vmtranslator.C_LABEL:
+	case vmtranslator.C_GOTO:
+		vm.jump(c.Arg1)
+	case vmtranslator.C_IF:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if v != 0 {
+			vm.jump(c.Arg1)
+		}
+	case vmtranslator.C_IFNOT:
+		v, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if v != -1 {
+			vm.jump(c.Arg1)
+		}
+	case vmtranslator.C_FUNCTION:
+		for i := 0; i < c.Arg2; i++ {
+			if err := vm.push(0); err != nil {
+				return err
+			}
+		}
+	case vmtranslator.C_CALL:
+		return vm.call(c.Arg1, c.Arg2, vm.PC)
+	case vmtranslator.C_RETURN:
+		return vm.ret()
+	default:
+		return fmt.Errorf("unknown command type %s", c.Type)
+	}
+	return nil
+}
+
+// jump continues at label in the current function, PC already points past
+// the jump
+func (vm *VM) jump(label string) {
+	vm.PC = vm.labels[scopedLabel(vm.function[vm.PC-1], label)]
+}
+
+func (vm *VM) arithmetic(op string) error {
+	y, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	switch op {
+	case "neg":
+		return vm.push(-y)
+	case "not":
+		return vm.push(^y)
+	}
+	x, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	switch op {
+	case "add":
+		return vm.push(x + y)
+	case "sub":
+		return vm.push(x - y)
+	case "and":
+		return vm.push(x & y)
+	case "or":
+		return vm.push(x | y)
+	case "eq":
+		return vm.push(vmBool(x == y))
+	case "gt":
+		return vm.push(vmBool(x > y))
+	case "lt":
+		return vm.push(vmBool(x < y))
+	}
+	return fmt.Errorf("unknown arithmetic command")
+}
+
+func vmBool(b bool) int16 {
+	if b {
+		return -1
+	}
+	return 0
+}
+
+// address returns the RAM address of index in segment
+func (vm *VM) address(segment string, index int) (int, error) {
+	switch segment {
+	case "local":
+		return int(vm.RAM[LCL]) + index, nil
+	case "argument":
+		return int(vm.RAM[ARG]) + index, nil
+	case "this":
+		return int(vm.RAM[THIS]) + index, nil
+	case "that":
+		return int(vm.RAM[THAT]) + index, nil
+	case "pointer":
+		return THIS + index, nil
+	case "temp":
+		return Temp + index, nil
+	case "static":
+		address, ok := vm.StaticAddress(vm.modules[vm.PC-1], index)
+		if !ok {
+			return 0, fmt.Errorf("static %d is not allocated", index)
+		}
+		return address, nil
+	}
+	return 0, fmt.Errorf("unknown segment %s", segment)
+}
+
+func (vm *VM) load(address int) (int16, error) {
+	if address < 0 || address >= len(vm.RAM) {
+		return 0, fmt.Errorf("RAM[%d] is outside of RAM", address)
+	}
+	return vm.RAM[address], nil
+}
+
+func (vm *VM) store(address int, v int16) error {
+	if address < 0 || address >= len(vm.RAM) {
+		return fmt.Errorf("RAM[%d] is outside of RAM", address)
+	}
+	vm.RAM[address] = v
+	return nil
+}
+
+func (vm *VM) push(v int16) error {
+	if err := vm.store(int(vm.RAM[SP]), v); err != nil {
+		return err
+	}
+	vm.RAM[SP]++
+	return nil
+}
+
+func (vm *VM) pop() (int16, error) {
+	vm.RAM[SP]--
+	return vm.load(int(vm.RAM[SP]))
+}
+
+// call pushes the frame of the caller and continues at function
+func (vm *VM) call(function string, nArgs int, returnAddress int) error {
+	i, ok := vm.functions[function]
+	if !ok {
+		return fmt.Errorf("function is not defined: %q", function)
+	}
+	for _, v := range []int16{int16(returnAddress), vm.RAM[LCL], vm.RAM[ARG], vm.RAM[THIS], vm.RAM[THAT]} {
+		if err := vm.push(v); err != nil {
+			return err
+		}
+	}
+	vm.RAM[ARG] = vm.RAM[SP] - 5 - int16(nArgs)
+	vm.RAM[LCL] = vm.RAM[SP]
+	vm.PC = i
+	return nil
+}
+
+// ret puts the return value where the caller's arguments were, restores
+// the frame of the caller and continues at the return address
+func (vm *VM) ret() error {
+	frame := int(vm.RAM[LCL])
+	saved := make([]int16, 5)
+	for i := range saved {
+		v, err := vm.load(frame - 5 + i)
+		if err != nil {
+			return err
+		}
+		saved[i] = v
+	}
+	v, err := vm.pop()
+	if err != nil {
+		return err
+	}
+	if err := vm.store(int(vm.RAM[ARG]), v); err != nil {
+		return err
+	}
+	vm.RAM[SP] = vm.RAM[ARG] + 1
+	vm.RAM[THAT] = saved[4]
+	vm.RAM[THIS] = saved[3]
+	vm.RAM[ARG] = saved[2]
+	vm.RAM[LCL] = saved[1]
+	vm.PC = int(uint16(saved[0]))
+	return nil
+}
+
+// Peek returns RAM[address]
+func (vm *VM) Peek(address int) int16 {
+	return vm.RAM[address]
+}
+
+// Poke sets RAM[address] to value
+func (vm *VM) Poke(address int, value int16) {
+	vm.RAM[address] = value
+}
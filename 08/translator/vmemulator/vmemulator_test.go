@@ -0,0 +1,232 @@
+package vmemulator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"translator/vmemulator"
+	"translator/vmtranslator"
+)
+
+// newVM returns a VM with the modules parsed from sources, keyed by module
+// name and loaded in the order of names
+func newVM(t *testing.T, names []string, sources map[string]string) *vmemulator.VM {
+	t.Helper()
+	var modules []vmtranslator.Module
+	for _, name := range names {
+		commands, err := vmtranslator.Parse(strings.NewReader(sources[name]), name+".vm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		modules = append(modules, vmtranslator.Module{Name: name, Commands: commands})
+	}
+	vm, err := vmemulator.New(modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+// loadProgram returns a VM with the vm files of the program directory dir
+func loadProgram(t *testing.T, dir string) *vmemulator.VM {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.vm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	sources := map[string]string{}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".vm")
+		names = append(names, name)
+		sources[name] = string(src)
+	}
+	return newVM(t, names, sources)
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		op   string
+		x, y int16
+		want int16
+	}{
+		{"add", 2, 3, 5},
+		{"add", 32767, 1, -32768},
+		{"add", -32768, -1, 32767},
+		{"sub", 2, 3, -1},
+		{"sub", -32768, 1, 32767},
+		{"sub", 32767, -1, -32768},
+		{"and", 12, 10, 8},
+		{"or", 12, 10, 14},
+		{"eq", -32768, -32768, -1},
+		{"eq", 1, 2, 0},
+		{"gt", 32767, -32768, -1},
+		{"gt", -32768, 32767, 0},
+		{"lt", -32768, 32767, -1},
+		{"lt", 32767, -32768, 0},
+		{"lt", 1, 1, 0},
+		// unary commands only use y
+		{"neg", 0, 5, -5},
+		{"neg", 0, -32768, -32768},
+		{"not", 0, 0, -1},
+		{"not", 0, 5, -6},
+	}
+	for _, test := range tests {
+		vm := newVM(t, []string{"Main"}, map[string]string{"Main": test.op + "\n"})
+		vm.Poke(vmemulator.SP, 258)
+		vm.Poke(256, test.x)
+		vm.Poke(257, test.y)
+		if err := vm.Step(); err != nil {
+			t.Fatal(err)
+		}
+		sp := int16(257)
+		if test.op == "neg" || test.op == "not" {
+			sp = 258
+		}
+		if got := vm.Peek(int(sp) - 1); got != test.want {
+			t.Errorf("%d %s %d: got %d, want %d", test.x, test.op, test.y, got, test.want)
+		}
+		if got := vm.Peek(vmemulator.SP); got != sp {
+			t.Errorf("%d %s %d: SP is %d, want %d", test.x, test.op, test.y, got, sp)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	sources := map[string]string{
+		"A": "push constant 11\npop local 2\npush constant 12\npop argument 1\n" +
+			"push constant 3000\npop pointer 0\npush constant 4000\npop pointer 1\n" +
+			"push constant 13\npop this 3\npush constant 14\npop that 4\n" +
+			"push constant 15\npop temp 0\npush constant 16\npop temp 7\n" +
+			"push constant 17\npop static 0\npush constant 18\npop static 3\n",
+		// B reads the segments back into temp 1-4, its static 0 is not the
+		// one of A
+		"B": "push constant 19\npop static 0\n" +
+			"push local 2\npush argument 1\nadd\npop temp 1\n" +
+			"push this 3\npush that 4\nadd\npop temp 2\n" +
+			"push pointer 0\npush pointer 1\nsub\npop temp 3\n" +
+			"push static 0\npush temp 7\nsub\npop temp 4\n",
+		"C": "push static 0\npop temp 5\n",
+	}
+	vm := newVM(t, []string{"A", "B", "C"}, sources)
+	for address, v := range map[int]int16{vmemulator.SP: 256, vmemulator.LCL: 300, vmemulator.ARG: 400} {
+		vm.Poke(address, v)
+	}
+	if err := vm.Run(1000); err != nil {
+		t.Fatal(err)
+	}
+
+	statics := []struct {
+		module  string
+		index   int
+		address int
+	}{
+		{"A", 0, 16},
+		{"A", 3, 17},
+		{"B", 0, 18},
+		{"C", 0, 19},
+	}
+	for _, s := range statics {
+		if got, ok := vm.StaticAddress(s.module, s.index); !ok || got != s.address {
+			t.Errorf("static %d of %s: got %d, %v, want %d", s.index, s.module, got, ok, s.address)
+		}
+	}
+	if _, ok := vm.StaticAddress("A", 1); ok {
+		t.Error("static 1 of A has an address")
+	}
+
+	want := map[int]int16{
+		vmemulator.SP: 256, 302: 11, 401: 12,
+		vmemulator.THIS: 3000, vmemulator.THAT: 4000, 3003: 13, 4004: 14,
+		5: 15, 12: 16, 16: 17, 17: 18, 18: 19, 19: 0,
+		6: 11 + 12, 7: 13 + 14, 8: 3000 - 4000, 9: 19 - 16, 10: 0,
+	}
+	for address, v := range want {
+		if got := vm.Peek(address); got != v {
+			t.Errorf("RAM[%d]: got %d, want %d", address, got, v)
+		}
+	}
+}
+
+// TestCallFrame checks the frame call pushes, the pointers it sets and
+// that return restores them
+func TestCallFrame(t *testing.T) {
+	sources := map[string]string{
+		"Main": "function Main.main 0\npush constant 3\npush constant 4\ncall Main.add 2\nlabel END\ngoto END\n" +
+			"function Main.add 1\npush argument 0\npush argument 1\nadd\npop local 0\npush local 0\nreturn\n",
+	}
+	vm := newVM(t, []string{"Main"}, sources)
+	for address, v := range map[int]int16{vmemulator.SP: 256, vmemulator.LCL: 256, vmemulator.ARG: 250,
+		vmemulator.THIS: 3000, vmemulator.THAT: 4000} {
+		vm.Poke(address, v)
+	}
+	if err := vm.Start("Main.main"); err != nil {
+		t.Fatal(err)
+	}
+	// function, push, push, call, function
+	for i := 0; i < 5; i++ {
+		if err := vm.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := vm.Function(); got != "Main.add" {
+		t.Fatalf("running %s, want Main.add", got)
+	}
+	// the return address is the index of the command after the call
+	want := map[int]int16{
+		256: 3, 257: 4, 258: 4, 259: 256, 260: 250, 261: 3000, 262: 4000, 263: 0,
+		vmemulator.SP: 264, vmemulator.LCL: 263, vmemulator.ARG: 256,
+	}
+	for address, v := range want {
+		if got := vm.Peek(address); got != v {
+			t.Errorf("in Main.add RAM[%d]: got %d, want %d", address, got, v)
+		}
+	}
+
+	if err := vm.Run(100); err != nil {
+		t.Fatal(err)
+	}
+	want = map[int]int16{
+		256: 7, vmemulator.SP: 257, vmemulator.LCL: 256, vmemulator.ARG: 250,
+		vmemulator.THIS: 3000, vmemulator.THAT: 4000,
+	}
+	for address, v := range want {
+		if got := vm.Peek(address); got != v {
+			t.Errorf("after return RAM[%d]: got %d, want %d", address, got, v)
+		}
+	}
+}
+
+// TestPrograms runs the programs with calls from the bootstrap call of
+// Sys.init and checks the RAM their compare files expect
+func TestPrograms(t *testing.T) {
+	programs := []struct {
+		dir  string
+		want map[int]int16
+	}{
+		{"FunctionCalls/FibonacciElement", map[int]int16{0: 262, 261: 3}},
+		{"FunctionCalls/StaticsTest", map[int]int16{0: 263, 261: -2, 262: 8}},
+	}
+	for _, program := range programs {
+		t.Run(program.dir, func(t *testing.T) {
+			vm := loadProgram(t, filepath.Join("../..", program.dir))
+			if err := vm.Bootstrap("Sys.init"); err != nil {
+				t.Fatal(err)
+			}
+			if err := vm.Run(10000); err != nil {
+				t.Fatal(err)
+			}
+			for address, want := range program.want {
+				if got := vm.Peek(address); got != want {
+					t.Errorf("RAM[%d]: got %d, want %d", address, got, want)
+				}
+			}
+		})
+	}
+}
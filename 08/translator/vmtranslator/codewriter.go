@@ -0,0 +1,561 @@
+package vmtranslator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const stackPointerDefault = 256
+
+type codeWriter struct {
+	out io.Writer
+	// lines is the generated assembly, it is written to out on close
+	lines []asmLine
+	// origins is the index in sources of the vm command each line was
+	// written for, or -1
+	origins  []int
+	sources  []SourceEntry
+	source   int
+	optimize bool
+	// sharedRoutines replaces inlined call, return and comparison code
+	// with jumps to the routines in routines.go
+	sharedRoutines bool
+	usedRoutines   map[string]bool
+	// fastCompare compares with x-y only, which is wrong when it overflows
+	fastCompare bool
+	// sourceMap is filled by close if it is not nil
+	sourceMap  *SourceMap
+	vmFileName string
+	stackIndex int
+	cmdCount   int
+	// functionName is the function currently being written, labels are
+	// scoped to it as functionName$label
+	functionName string
+	// pos is the position of the vm command being written, used for errors
+	pos Position
+	// instructions is the number of instructions before and after the
+	// peephole optimizer, set by close
+	instructions int
+	optimized    int
+}
+
+func newCodeWriter(out io.Writer, opts Options) *codeWriter {
+	return &codeWriter{
+		out:            out,
+		optimize:       opts.Optimize,
+		sharedRoutines: opts.SharedRoutines,
+		usedRoutines:   map[string]bool{},
+		fastCompare:    opts.FastCompare,
+		source:         -1,
+		sourceMap:      opts.SourceMap,
+		stackIndex:     stackPointerDefault,
+	}
+}
+
+func (c *codeWriter) writeInit() {
+	c.setSource(SourceEntry{Command: "bootstrap"})
+	c.writeCommand("// ** start init\n")
+	initStack := "@256\nD=A\n@SP\nM=D\n"
+	c.writeCommand(initStack)
+	c.writeCall("Sys.init", 0)
+	c.writeCommand("// ** end init\n")
+}
+
+func (c *codeWriter) setFileName(fileName string) {
+	c.vmFileName = fileName
+}
+
+func (c *codeWriter) setPosition(pos Position) {
+	c.pos = pos
+}
+
+// errorf returns a diagnostic pointing at the vm command being written
+func (c *codeWriter) errorf(token string, format string, args ...interface{}) error {
+	return newDiagnostic(c.pos, token, format, args...)
+}
+
+// setSource makes source the origin of the lines written from now on
+func (c *codeWriter) setSource(source SourceEntry) {
+	c.sources = append(c.sources, source)
+	c.source = len(c.sources) - 1
+}
+
+func (c *codeWriter) writeCommand(cmd string) {
+	for _, line := range strings.SplitAfter(cmd, "\n") {
+		if line != "" {
+			c.lines = append(c.lines, asmLine(strings.TrimSuffix(line, "\n")))
+			c.origins = append(c.origins, c.source)
+		}
+	}
+	c.cmdCount++
+}
+
+func (c *codeWriter) writeArithmetic(cmd string) error {
+	cmdCount := strconv.Itoa(c.cmdCount)
+	getStackTop := "@SP\n" +
+		"M=M-1\n" +
+		"A=M\n"
+
+	incrStack := "@SP\n" +
+		"M=M+1\n"
+
+	alu2ParamCommand := getStackTop +
+		"D=M\n" +
+		"@SP\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"M=%s\n" + // M=M+D, M=M-D, M=M&D, M=M|D
+		incrStack + "\n"
+
+	alu1ParamCommand := getStackTop +
+		"M=%s\n" + // M=-M, M=!M
+		incrStack + "\n"
+
+	// x-y has the sign of the comparison for eq, but for gt and lt it can
+	// overflow when x and y have different signs, then the sign of x decides.
+	// fastCompare uses the subtraction for gt and lt anyway.
+	cmpTail := "@CMD" + cmdCount + "\n" +
+		"D;%s\n" + // JEQ, JGT, JLT
+		"@SP\n" +
+		"A=M\n" +
+		"M=0\n" +
+		"@END" + cmdCount + "\n" +
+		"0;JMP\n" +
+		"(CMD" + cmdCount + ")\n" +
+		"@SP\n" +
+		"A=M\n" +
+		"M=-1\n" +
+		"(END" + cmdCount + ")\n" +
+		incrStack
+
+	subCmpCommand := getStackTop +
+		"D=M\n" +
+		getStackTop +
+		"D=M-D\n" +
+		cmpTail
+
+	signedCmpCommand := getStackTop +
+		"D=M\n" +
+		"@YNEG" + cmdCount + "\n" +
+		"D;JLT\n" +
+		getStackTop +
+		"D=M\n" +
+		"@SUB" + cmdCount + "\n" +
+		"D;JGE\n" +
+		"D=-1\n" + // x < 0 <= y
+		"@TEST" + cmdCount + "\n" +
+		"0;JMP\n" +
+		"(YNEG" + cmdCount + ")\n" +
+		getStackTop +
+		"D=M\n" +
+		"@SUB" + cmdCount + "\n" +
+		"D;JLT\n" +
+		"D=1\n" + // y < 0 <= x
+		"@TEST" + cmdCount + "\n" +
+		"0;JMP\n" +
+		"(SUB" + cmdCount + ")\n" + // same signs, x-y can't overflow
+		"@SP\n" +
+		"A=M+1\n" +
+		"D=M\n" +
+		"A=A-1\n" +
+		"D=M-D\n" +
+		"(TEST" + cmdCount + ")\n" +
+		cmpTail
+
+	gtLtCommand := signedCmpCommand
+	if c.fastCompare {
+		gtLtCommand = subCmpCommand
+	}
+
+	if _, ok := compareMasks[cmd]; ok && c.sharedRoutines {
+		c.writeCommand(fmt.Sprintf("// %s\n", cmd) + c.sharedCompare(cmd))
+		return nil
+	}
+
+	var asmC string
+	switch cmd {
+	case "add":
+		asmC = "// add\n"
+		asmC += fmt.Sprintf(alu2ParamCommand, "M+D")
+	case "sub":
+		asmC = "// sub\n"
+		asmC += fmt.Sprintf(alu2ParamCommand, "M-D")
+	case "neg":
+		asmC = "// neg\n"
+		asmC += fmt.Sprintf(alu1ParamCommand, "-M")
+	case "eq":
+		asmC = "// eq\n"
+		asmC += fmt.Sprintf(subCmpCommand, "JEQ")
+	case "gt": // x > y
+		asmC = "// gt\n"
+		asmC += fmt.Sprintf(gtLtCommand, "JGT")
+	case "lt": // x < y
+		asmC = "// lt\n"
+		asmC += fmt.Sprintf(gtLtCommand, "JLT")
+	case "and":
+		asmC = "// and\n"
+		asmC += fmt.Sprintf(alu2ParamCommand, "M&D")
+	case "or":
+		asmC = "// or\n"
+		asmC += fmt.Sprintf(alu2ParamCommand, "M|D")
+	case "not":
+		asmC = "// not\n"
+		asmC += fmt.Sprintf(alu1ParamCommand, "!M")
+	default:
+		return c.errorf(cmd, "unknown arithmetic command")
+	}
+	c.writeCommand(asmC)
+	return nil
+}
+
+func (c *codeWriter) writePushPop(cmd CommandType, segment string, index int) error {
+	pushDToStack := "@SP\n" +
+		"A=M\n" +
+		"M=D\n" +
+		"@SP\n" +
+		"M=M+1\n"
+
+	pushSegmentToStack := "@%s\n" +
+		"D=M\n" +
+		"@%d\n" +
+		"A=A+D\n" +
+		"D=M\n" +
+		pushDToStack
+
+	pushRamToStack := "@%s\n" +
+		"D=A\n" +
+		"@%d\n" +
+		"A=A+D\n" +
+		"D=M\n" +
+		pushDToStack
+
+	popStackToD := "@SP\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"D=M\n"
+
+	popStackToRam := "@%s\n" +
+		"D=A\n" +
+		"@%d\n" +
+		"D=A+D\n" +
+		"@R13\n" +
+		"M=D\n" +
+		popStackToD +
+		"@R13\n" +
+		"A=M\n" +
+		"M=D\n"
+
+	popStackToSegment := "@%s\n" +
+		"D=M\n" +
+		"@%d\n" +
+		"D=A+D\n" +
+		"@R13\n" +
+		"M=D\n" +
+		popStackToD +
+		"@R13\n" +
+		"A=M\n" +
+		"M=D\n"
+
+	switch cmd {
+	case C_PUSH:
+		switch segment {
+		case "constant":
+			cmd := fmt.Sprintf("// push constant %d\n", index)
+			// negative constants only come from OptimizeVM
+			switch {
+			case index >= 0:
+				cmd += fmt.Sprintf("@%d\n", index) +
+					"D=A\n"
+			case index == -1:
+				cmd += "D=-1\n"
+			case index == -32768:
+				cmd += "@32767\n" +
+					"D=-A\n" +
+					"D=D-1\n"
+			default:
+				cmd += fmt.Sprintf("@%d\n", -index) +
+					"D=-A\n"
+			}
+			cmd += pushDToStack
+			c.writeCommand(cmd)
+		case "local":
+			cmd := fmt.Sprintf("// push local %d\n", index)
+			cmd += fmt.Sprintf(pushSegmentToStack, "LCL", index)
+			c.writeCommand(cmd)
+		case "argument":
+			cmd := fmt.Sprintf("// push argument %d\n", index)
+			cmd += fmt.Sprintf(pushSegmentToStack, "ARG", index)
+			c.writeCommand(cmd)
+		case "this":
+			cmd := fmt.Sprintf("// push this %d\n", index)
+			cmd += fmt.Sprintf(pushSegmentToStack, "THIS", index)
+			c.writeCommand(cmd)
+		case "that":
+			cmd := fmt.Sprintf("// push that %d\n", index)
+			cmd += fmt.Sprintf(pushSegmentToStack, "THAT", index)
+			c.writeCommand(cmd)
+		case "pointer":
+			cmd := fmt.Sprintf("// push pointer %d\n", index)
+			cmd += fmt.Sprintf(pushRamToStack, "THIS", index)
+			c.writeCommand(cmd)
+		case "temp":
+			cmd := fmt.Sprintf("// push temp %d\n", index)
+			cmd += fmt.Sprintf(pushRamToStack, "R5", index)
+			c.writeCommand(cmd)
+		case "static":
+			cmd := fmt.Sprintf("// push static %d\n", index)
+			cmd += fmt.Sprintf("@static.%s.%d\n", c.vmFileName, index)
+			cmd += "D=M\n"
+			cmd += pushDToStack
+			c.writeCommand(cmd)
+		default:
+			return c.errorf(segment, "unknown push segment")
+		}
+	case C_POP:
+		switch segment {
+		case "local":
+			cmd := fmt.Sprintf("// pop local %d\n", index)
+			cmd += fmt.Sprintf(popStackToSegment, "LCL", index)
+			c.writeCommand(cmd)
+		case "argument":
+			cmd := fmt.Sprintf("// pop argument %d\n", index)
+			cmd += fmt.Sprintf(popStackToSegment, "ARG", index)
+			c.writeCommand(cmd)
+		case "this":
+			cmd := fmt.Sprintf("// pop this %d\n", index)
+			cmd += fmt.Sprintf(popStackToSegment, "THIS", index)
+			c.writeCommand(cmd)
+		case "that":
+			cmd := fmt.Sprintf("// pop that %d\n", index)
+			cmd += fmt.Sprintf(popStackToSegment, "THAT", index)
+			c.writeCommand(cmd)
+		case "pointer":
+			cmd := fmt.Sprintf("// pop pointer %d\n", index)
+			cmd += fmt.Sprintf(popStackToRam, "THIS", index)
+			c.writeCommand(cmd)
+		case "temp":
+			cmd := fmt.Sprintf("// pop temp %d\n", index)
+			cmd += fmt.Sprintf(popStackToRam, "R5", index)
+			c.writeCommand(cmd)
+		case "static":
+			cmd := fmt.Sprintf("// pop static %d\n", index)
+			cmd += popStackToD
+			cmd += fmt.Sprintf("@static.%s.%d\n", c.vmFileName, index)
+			cmd += "M=D\n"
+			c.writeCommand(cmd)
+		default:
+			return c.errorf(segment, "unknown pop segment")
+		}
+	default:
+		return c.errorf(string(cmd), "not a push or pop command")
+	}
+	return nil
+}
+
+// scopedLabel returns the label as functionName$label, labels outside of
+// any function are left as they are
+func (c *codeWriter) scopedLabel(label string) string {
+	if c.functionName == "" {
+		return label
+	}
+	return c.functionName + "$" + label
+}
+
+func (c *codeWriter) writeLabel(label string) {
+	c.writeCommand(fmt.Sprintf("(%s)\n", c.scopedLabel(label)))
+}
+
+func (c *codeWriter) writeGoto(label string) {
+	c.writeJump(label, c.scopedLabel(label))
+}
+
+// writeJump writes an unconditional jump to an already resolved asm label
+func (c *codeWriter) writeJump(label string, asmLabel string) {
+	gotoCmd := "@%s\n" +
+		"0;JMP\n"
+	c.writeCommand(fmt.Sprintf("// goto %s\n", label))
+	c.writeCommand(fmt.Sprintf(gotoCmd, asmLabel))
+}
+
+func (c *codeWriter) writeIf(label string) {
+	popStackToD := "@SP\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"D=M\n"
+	ifGoto := "@%s\n" +
+		"D;JNE\n"
+	c.writeCommand(fmt.Sprintf("// if-goto %s\n", label))
+	c.writeCommand(fmt.Sprintf(popStackToD+ifGoto, c.scopedLabel(label)))
+}
+
+// writeIfNot writes `not; if-goto label` as a jump unless the top of the
+// stack is -1
+func (c *codeWriter) writeIfNot(label string) {
+	ifNotGoto := "@SP\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"D=M+1\n" +
+		"@%s\n" +
+		"D;JNE\n"
+	c.writeCommand(fmt.Sprintf("// not; if-goto %s\n", label))
+	c.writeCommand(fmt.Sprintf(ifNotGoto, c.scopedLabel(label)))
+}
+
+func (c *codeWriter) writeCall(functionName string, numArgs int) {
+	if c.sharedRoutines {
+		c.writeSharedCall(functionName, numArgs)
+		return
+	}
+	pushDToStack := "@SP\n" +
+		"A=M\n" +
+		"M=D\n" +
+		"@SP\n" +
+		"M=M+1\n"
+	returnAddress := fmt.Sprintf("%s:%d:%d", functionName, numArgs, c.cmdCount)
+	c.writeCommand(fmt.Sprintf("// ** start call %s %d **\n", functionName, numArgs))
+	c.writeCommand("// push return-address\n")
+	pushRetAddr := "@%s\n" +
+		"D=A\n" +
+		pushDToStack
+	c.writeCommand(fmt.Sprintf(pushRetAddr, returnAddress))
+	pushPointerToStack := "@%s\n" +
+		"D=M\n" +
+		pushDToStack
+	c.writeCommand("// push LCL\n")
+	c.writeCommand(fmt.Sprintf(pushPointerToStack, "LCL"))
+	c.writeCommand("// push ARG\n")
+	c.writeCommand(fmt.Sprintf(pushPointerToStack, "ARG"))
+	c.writeCommand("// push THIS\n")
+	c.writeCommand(fmt.Sprintf(pushPointerToStack, "THIS"))
+	c.writeCommand("// push THAT\n")
+	c.writeCommand(fmt.Sprintf(pushPointerToStack, "THAT"))
+	setARG := "@SP\n" +
+		"D=M\n" +
+		"@5\n" +
+		"D=D-A\n" +
+		"@%d\n" +
+		"D=D-A\n" +
+		"@ARG\n" +
+		"M=D\n"
+	c.writeCommand("// ARG = SP - n - 5\n")
+	c.writeCommand(fmt.Sprintf(setARG, numArgs))
+	// LCL = SP
+	setLCL := "@SP\n" +
+		"D=M\n" +
+		"@LCL\n" +
+		"M=D\n"
+	c.writeCommand("// LCL = SP\n")
+	c.writeCommand(setLCL)
+	c.writeCommand("// goto f\n")
+	c.writeJump(functionName, functionName)
+	c.writeCommand("// label return-address\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", returnAddress))
+	c.writeCommand(fmt.Sprintf("// ** end call %s %d **\n", functionName, numArgs))
+}
+
+func (c *codeWriter) writeReturn() {
+	if c.sharedRoutines {
+		c.writeSharedReturn()
+		return
+	}
+	c.writeCommand("// ** start return **\n")
+	c.writeReturnBody()
+	c.writeCommand("// ** end return **\n")
+}
+
+// writeReturnBody writes the return code without the start and end comments
+func (c *codeWriter) writeReturnBody() {
+	frame := "@LCL\n" +
+		"D=M\n" +
+		"@R13\n" +
+		"M=D\n"
+	c.writeCommand("// FRAME = LCL\n")
+	c.writeCommand(frame)
+
+	ret := "@5\n" +
+		"A=D-A\n" +
+		"D=M\n" +
+		"@R14\n" +
+		"M=D\n"
+	c.writeCommand("// RET = *(FRAME - 5)\n")
+	c.writeCommand(ret)
+
+	popStackToD := "@SP\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"D=M\n"
+	argPop := popStackToD +
+		"@ARG\n" +
+		"A=M\n" +
+		"M=D\n"
+	c.writeCommand("// *ARG = pop()\n")
+	c.writeCommand(argPop)
+
+	restoreSP := "@ARG\n" +
+		"A=M\n" +
+		"D=A+1\n" +
+		"@SP\n" +
+		"M=D\n"
+	c.writeCommand("// SP = ARG + 1\n")
+	c.writeCommand(restoreSP)
+
+	c.writeCommand("// THAT THIS ARG LCL\n")
+	frame1 := "@R13\n" +
+		"M=M-1\n" +
+		"A=M\n" +
+		"D=M\n"
+	that := frame1 +
+		"@THAT\n" +
+		"M=D\n"
+	c.writeCommand(that)
+	this := frame1 +
+		"@THIS\n" +
+		"M=D\n"
+	c.writeCommand(this)
+	arg := frame1 +
+		"@ARG\n" +
+		"M=D\n"
+	c.writeCommand(arg)
+	lcl := frame1 +
+		"@LCL\n" +
+		"M=D\n"
+	c.writeCommand(lcl)
+	gotoRET := "@R14\n" +
+		"A=M\n" +
+		"0;JMP\n"
+
+	c.writeCommand("// goto RET\n")
+	c.writeCommand(gotoRET)
+}
+
+func (c *codeWriter) writeFunction(functionName string, numLocals int) {
+	c.functionName = functionName
+	c.writeCommand(fmt.Sprintf("// function %s %d\n", functionName, numLocals))
+	c.writeCommand(fmt.Sprintf("(%s)\n", functionName))
+	for i := 0; i < numLocals; i++ {
+		c.writePushPop(C_PUSH, "constant", 0)
+	}
+}
+
+// close runs the peephole optimizer if it is enabled and writes the
+// assembly
+func (c *codeWriter) close() error {
+	c.writeRoutines()
+	c.instructions = countInstructions(c.lines)
+	if c.optimize {
+		c.lines, c.origins = optimize(c.lines, c.origins)
+	}
+	c.optimized = countInstructions(c.lines)
+	if c.sourceMap != nil {
+		*c.sourceMap = c.buildSourceMap()
+	}
+	w := bufio.NewWriter(c.out)
+	for _, line := range c.lines {
+		w.WriteString(string(line))
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
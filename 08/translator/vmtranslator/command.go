@@ -0,0 +1,85 @@
+// Package vmtranslator translates programs of the nand2tetris VM language
+// into Hack assembly.
+package vmtranslator
+
+import "fmt"
+
+// CommandType is the kind of a vm command
+type CommandType string
+
+const (
+	C_ARITHMETIC CommandType = "C_ARITHMETIC"
+	C_PUSH       CommandType = "C_PUSH"
+	C_POP        CommandType = "C_POP"
+	C_LABEL      CommandType = "C_LABEL"
+	C_GOTO       CommandType = "C_GOTO"
+	C_IF         CommandType = "C_IF"
+	C_FUNCTION   CommandType = "C_FUNCTION"
+	C_RETURN     CommandType = "C_RETURN"
+	C_CALL       CommandType = "C_CALL"
+	// C_IFNOT is `not; if-goto`, it is only written by OptimizeVM
+	C_IFNOT CommandType = "C_IFNOT"
+	// C_INTRINSIC is a call of an OS function the translator writes the
+	// code of, it is only written by Translate with Options.Intrinsics
+	C_INTRINSIC CommandType = "C_INTRINSIC"
+)
+
+type segment string
+
+const (
+	argument segment = "argument"
+	local    segment = "local"
+	static   segment = "static"
+	constant segment = "constant"
+	this     segment = "this"
+	that     segment = "that"
+	pointer  segment = "pointer"
+	temp     segment = "temp"
+)
+
+// Command is a parsed line of a vm file. Arg1 is the operation for
+// C_ARITHMETIC, Arg2 is -1 for commands that don't take a second argument.
+type Command struct {
+	Type    CommandType
+	Arg1    string
+	Arg2    int
+	Line    string
+	Pos     Position
+	Arg1Pos Position
+	Arg2Pos Position
+}
+
+// Module is the commands of one vm file, Name is the file name without the
+// .vm extension and is used to name the static variables of the file
+type Module struct {
+	Name     string
+	Commands []Command
+}
+
+// String returns the command as it is written in a vm file, C_IFNOT is
+// written as the two commands it replaces and C_INTRINSIC as the call
+func (c Command) String() string {
+	switch c.Type {
+	case C_ARITHMETIC:
+		return c.Arg1
+	case C_PUSH:
+		return fmt.Sprintf("push %s %d", c.Arg1, c.Arg2)
+	case C_POP:
+		return fmt.Sprintf("pop %s %d", c.Arg1, c.Arg2)
+	case C_LABEL:
+		return "label " + c.Arg1
+	case C_GOTO:
+		return "goto " + c.Arg1
+	case C_IF:
+		return "if-goto " + c.Arg1
+	case C_IFNOT:
+		return "not; if-goto " + c.Arg1
+	case C_FUNCTION:
+		return fmt.Sprintf("function %s %d", c.Arg1, c.Arg2)
+	case C_CALL, C_INTRINSIC:
+		return fmt.Sprintf("call %s %d", c.Arg1, c.Arg2)
+	case C_RETURN:
+		return "return"
+	}
+	return c.Line
+}
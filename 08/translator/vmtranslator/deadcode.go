@@ -0,0 +1,89 @@
+package vmtranslator
+
+import "io"
+
+// RemovedFunction is a function RemoveDeadFunctions left out. Instructions
+// is the number of ROM words its translation takes without optimizations,
+// it is only set by Translate.
+type RemovedFunction struct {
+	Module       string
+	Name         string
+	Commands     []Command
+	Instructions int
+}
+
+// function is the commands of a function from its function command up to
+// the next one
+type function struct {
+	name     string
+	commands []Command
+	calls    []string
+}
+
+// splitFunctions splits the commands of a module into its functions, the
+// commands before the first function are returned as one without a name
+func splitFunctions(commands []Command) []function {
+	var functions []function
+	for _, c := range commands {
+		if c.Type == C_FUNCTION || len(functions) == 0 {
+			functions = append(functions, function{})
+		}
+		f := &functions[len(functions)-1]
+		if c.Type == C_FUNCTION {
+			f.name = c.Arg1
+		}
+		if c.Type == C_CALL {
+			f.calls = append(f.calls, c.Arg1)
+		}
+		f.commands = append(f.commands, c)
+	}
+	return functions
+}
+
+// RemoveDeadFunctions returns the modules without the functions that can't
+// be reached from root through calls. Commands outside of any function are
+// always kept. The modules passed in are not changed.
+func RemoveDeadFunctions(modules []Module, root string) ([]Module, []RemovedFunction) {
+	calls := map[string][]string{}
+	for _, module := range modules {
+		for _, f := range splitFunctions(module.Commands) {
+			calls[f.name] = append(calls[f.name], f.calls...)
+		}
+	}
+
+	reachable := map[string]bool{"": true, root: true}
+	queue := []string{"", root}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, callee := range calls[name] {
+			if !reachable[callee] {
+				reachable[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+
+	var kept []Module
+	var removed []RemovedFunction
+	for _, module := range modules {
+		var commands []Command
+		for _, f := range splitFunctions(module.Commands) {
+			if reachable[f.name] {
+				commands = append(commands, f.commands...)
+			} else {
+				removed = append(removed, RemovedFunction{Module: module.Name, Name: f.name, Commands: f.commands})
+			}
+		}
+		kept = append(kept, Module{Name: module.Name, Commands: commands})
+	}
+	return kept, removed
+}
+
+// functionInstructions returns the number of ROM words commands translate to
+// without optimizations
+func functionInstructions(commands []Command, opts Options) int {
+	c := newCodeWriter(io.Discard, opts)
+	c.writeModule(Module{Commands: commands})
+	return countInstructions(c.lines)
+}
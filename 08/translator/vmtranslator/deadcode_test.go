@@ -0,0 +1,92 @@
+package vmtranslator
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+// deadCodeSources has an unreachable cycle (Main.a and Main.b) and a
+// function that is only called from a dead one (Main.helper)
+var deadCodeSources = map[string]string{
+	"Sys": "function Sys.init 0\ncall Main.main 0\nlabel END\ngoto END\n" +
+		"function Sys.halt 0\nlabel H\ngoto H\n",
+	"Main": "function Main.main 0\ncall Main.used 0\nreturn\n" +
+		"function Main.used 0\npush constant 1\nreturn\n" +
+		"function Main.a 0\ncall Main.b 0\nreturn\n" +
+		"function Main.b 1\npush local 0\ncall Main.a 1\nreturn\n" +
+		"function Main.dead 0\npush constant 2\npush constant 3\ngt\ncall Main.helper 1\nreturn\n" +
+		"function Main.helper 0\nreturn\n",
+}
+
+func TestRemoveDeadFunctions(t *testing.T) {
+	modules := parseModules(t, deadCodeSources)
+	n := len(modules[0].Commands)
+	kept, removed := RemoveDeadFunctions(modules, "Sys.init")
+
+	var names []string
+	for _, f := range removed {
+		names = append(names, f.Module+" "+f.Name)
+	}
+	want := []string{"Main Main.a", "Main Main.b", "Main Main.dead", "Main Main.helper", "Sys Sys.halt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("removed %q, want %q", names, want)
+	}
+
+	var functions []string
+	for _, module := range kept {
+		for _, c := range module.Commands {
+			if c.Type == C_FUNCTION {
+				functions = append(functions, c.Arg1)
+			}
+		}
+	}
+	if want := []string{"Main.main", "Main.used", "Sys.init"}; !reflect.DeepEqual(functions, want) {
+		t.Errorf("kept %q, want %q", functions, want)
+	}
+	if len(modules[0].Commands) != n {
+		t.Error("the modules passed in were changed")
+	}
+}
+
+// TestRemoveDeadFunctionsStats checks that the instructions of the removed
+// functions add up to what removing them saves
+func TestRemoveDeadFunctionsStats(t *testing.T) {
+	modes := map[string]Options{
+		"default": {},
+		"-O":      {Optimize: true},
+		"-Ovm":    {OptimizeVM: true},
+	}
+	for mode, opts := range modes {
+		t.Run(mode, func(t *testing.T) {
+			var full Stats
+			opts.Stats = &full
+			if err := Translate(parseModules(t, deadCodeSources), io.Discard, opts); err != nil {
+				t.Fatal(err)
+			}
+			var trimmed Stats
+			opts.Stats = &trimmed
+			opts.RemoveDeadFunctions = true
+			if err := Translate(parseModules(t, deadCodeSources), io.Discard, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			saved := 0
+			for _, f := range trimmed.Removed {
+				names = append(names, f.Name)
+				saved += f.Instructions
+				if f.Instructions <= 0 {
+					t.Errorf("%s has %d instructions", f.Name, f.Instructions)
+				}
+			}
+			if want := []string{"Main.a", "Main.b", "Main.dead", "Main.helper", "Sys.halt"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("removed %q, want %q", names, want)
+			}
+			if want := full.Instructions - trimmed.Instructions; saved != want {
+				t.Errorf("removed functions have %d instructions, the full program has %d more than the trimmed one",
+					saved, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package vmtranslator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Position of a token in a vm or jack file, Line and Column start at 1
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Diagnostic is an error found in a source file together with where it was
+// found
+type Diagnostic struct {
+	Pos   Position
+	Token string
+	Msg   string
+}
+
+func newDiagnostic(pos Position, token string, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{pos, token, fmt.Sprintf(format, args...)}
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Pos.File == "" {
+		return d.Msg
+	}
+	if d.Token == "" {
+		return fmt.Sprintf("%s: %s", d.Pos, d.Msg)
+	}
+	return fmt.Sprintf("%s: %s: %q", d.Pos, d.Msg, d.Token)
+}
+
+// DiagnosticList collects every error of a run so they can be reported
+// together instead of stopping at the first one
+type DiagnosticList []*Diagnostic
+
+func (l DiagnosticList) Error() string {
+	msgs := make([]string, len(l))
+	for i, d := range l {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Add appends err to the list, errors that are not diagnostics are kept
+// without a position
+func (l *DiagnosticList) Add(err error) {
+	switch err := err.(type) {
+	case nil:
+	case *Diagnostic:
+		*l = append(*l, err)
+	case DiagnosticList:
+		*l = append(*l, err...)
+	default:
+		*l = append(*l, &Diagnostic{Msg: err.Error()})
+	}
+}
+
+// Err returns the list as an error, or nil if it is empty
+func (l DiagnosticList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Report writes every diagnostic followed by the number of errors
+func (l DiagnosticList) Report(w io.Writer) {
+	for _, d := range l {
+		fmt.Fprintln(w, d.Error())
+	}
+	if len(l) == 1 {
+		fmt.Fprintln(w, "1 error")
+	} else {
+		fmt.Fprintf(w, "%d errors\n", len(l))
+	}
+}
@@ -0,0 +1,279 @@
+package vmtranslator
+
+import "fmt"
+
+// intrinsics are the OS functions Options.Intrinsics replaces with code of
+// the translator, by name and number of arguments. They behave like the
+// Jack OS: multiply keeps the low 16 bits of the product and divide
+// truncates towards 0.
+var intrinsics = map[string]int{
+	"Math.multiply": 2,
+	"Math.divide":   2,
+	"Memory.peek":   1,
+	"Memory.poke":   2,
+}
+
+// Math.multiply and Math.divide are shared routines, called with the return
+// address in D. They keep their state in R13-R15 and in variables the
+// assembler allocates after the static variables.
+const (
+	multiplyRoutine = "$$MULTIPLY"
+	divideRoutine   = "$$DIVIDE"
+)
+
+// IsIntrinsic reports whether a call of function with nArgs arguments is
+// replaced by code of the translator with Options.Intrinsics
+func IsIntrinsic(function string, nArgs int) bool {
+	n, ok := intrinsics[function]
+	return ok && n == nArgs
+}
+
+// replaceIntrinsics returns the modules with the calls of intrinsics turned
+// into C_INTRINSIC commands, the modules passed in are not changed
+func replaceIntrinsics(modules []Module) []Module {
+	replaced := make([]Module, len(modules))
+	for i, module := range modules {
+		commands := make([]Command, len(module.Commands))
+		for j, c := range module.Commands {
+			if c.Type == C_CALL && IsIntrinsic(c.Arg1, c.Arg2) {
+				c.Type = C_INTRINSIC
+			}
+			commands[j] = c
+		}
+		replaced[i] = Module{Name: module.Name, Commands: commands}
+	}
+	return replaced
+}
+
+// writeIntrinsic writes the code that replaces a call of function, the
+// arguments on the stack are replaced by the return value like a call does
+func (c *codeWriter) writeIntrinsic(function string) {
+	c.writeCommand(fmt.Sprintf("// %s (intrinsic)\n", function))
+	switch function {
+	case "Math.multiply":
+		c.writeRoutineCall(multiplyRoutine)
+	case "Math.divide":
+		c.writeRoutineCall(divideRoutine)
+	case "Memory.peek":
+		c.writeCommand("@SP\n" +
+			"A=M-1\n" +
+			"A=M\n" +
+			"D=M\n" +
+			"@SP\n" +
+			"A=M-1\n" +
+			"M=D\n")
+	case "Memory.poke":
+		// the address is replaced by the return value 0
+		c.writeCommand("@SP\n" +
+			"AM=M-1\n" +
+			"D=M\n" +
+			"A=A-1\n" +
+			"A=M\n" +
+			"M=D\n" +
+			"@SP\n" +
+			"A=M-1\n" +
+			"M=0\n")
+	}
+}
+
+// writeRoutineCall jumps to the shared routine with the return address in D
+func (c *codeWriter) writeRoutineCall(routine string) {
+	c.usedRoutines[routine] = true
+	returnAddress := fmt.Sprintf("%s_RET%d", routine, c.cmdCount)
+	c.writeCommand(fmt.Sprintf("@%s\n", returnAddress) +
+		"D=A\n" +
+		fmt.Sprintf("@%s\n", routine) +
+		"0;JMP\n" +
+		fmt.Sprintf("(%s)\n", returnAddress))
+}
+
+// writeMultiply writes $$MULTIPLY, which adds x shifted left once per bit
+// of y for the bits that are set and stops when no set bit of y is left
+func (c *codeWriter) writeMultiply() {
+	c.setSource(SourceEntry{Function: multiplyRoutine})
+	loop := multiplyRoutine + "_LOOP"
+	next := multiplyRoutine + "_NEXT"
+	end := multiplyRoutine + "_END"
+	c.writeCommand("// ** multiply: D = return-address, R13 = x shifted, R14 = bits of y left, R15 = bit **\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", multiplyRoutine) +
+		"@R15\n" +
+		"M=D\n" +
+		"@SP\n" +
+		"AM=M-1\n" +
+		"D=M\n" +
+		"@R14\n" +
+		"M=D\n")
+	c.writeCommand("// the return address goes where y was, the sum where x was\n")
+	c.writeCommand("@R15\n" +
+		"D=M\n" +
+		"@SP\n" +
+		"A=M\n" +
+		"M=D\n" +
+		"A=A-1\n" +
+		"D=M\n" +
+		"M=0\n" +
+		"@R13\n" +
+		"M=D\n" +
+		"@R15\n" +
+		"M=1\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", loop) +
+		"@R14\n" +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", end) +
+		"D;JEQ\n" +
+		"@R15\n" +
+		"D=M\n" +
+		"@R14\n" +
+		"D=D&M\n" +
+		fmt.Sprintf("@%s\n", next) +
+		"D;JEQ\n" +
+		"@R14\n" +
+		"M=M-D\n" +
+		"@R13\n" +
+		"D=M\n" +
+		"@SP\n" +
+		"A=M-1\n" +
+		"M=D+M\n" +
+		fmt.Sprintf("(%s)\n", next) +
+		"@R13\n" +
+		"D=M\n" +
+		"M=D+M\n" +
+		"@R15\n" +
+		"D=M\n" +
+		"M=D+M\n" +
+		fmt.Sprintf("@%s\n", loop) +
+		"0;JMP\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", end) +
+		"@SP\n" +
+		"A=M\n" +
+		"A=M\n" +
+		"0;JMP\n")
+}
+
+// writeDivide writes $$DIVIDE, a long division of |x| by |y| one bit at a
+// time from the top, with the sign applied to the quotient at the end.
+// |x| and |y| are unsigned so -32768 works. Division by 0 halts in
+// $$DIVIDE_BY_ZERO, where the Jack OS would call Sys.error.
+func (c *codeWriter) writeDivide() {
+	c.setSource(SourceEntry{Function: divideRoutine})
+	yPositive := divideRoutine + "_YPOS"
+	xPositive := divideRoutine + "_XPOS"
+	loop := divideRoutine + "_LOOP"
+	shifted := divideRoutine + "_SHIFTED"
+	bigY := divideRoutine + "_BIGY"
+	subtract := divideRoutine + "_SUB"
+	next := divideRoutine + "_NEXT"
+	positive := divideRoutine + "_POS"
+	byZero := divideRoutine + "_BY_ZERO"
+	remainder := divideRoutine + "_REMAINDER"
+	quotient := divideRoutine + "_QUOTIENT"
+	negative := divideRoutine + "_NEGATIVE"
+	bits := divideRoutine + "_BITS"
+	c.writeCommand("// ** divide: D = return-address, R13 = |x| shifted, R14 = |y|, R15 = return-address **\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", divideRoutine) +
+		"@R15\n" +
+		"M=D\n" +
+		"@SP\n" +
+		"AM=M-1\n" +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", byZero) +
+		"D;JEQ\n" +
+		"@R14\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", negative) +
+		"M=0\n" +
+		fmt.Sprintf("@%s\n", yPositive) +
+		"D;JGT\n" +
+		"@R14\n" +
+		"M=-M\n" +
+		fmt.Sprintf("@%s\n", negative) +
+		"M=!M\n" +
+		fmt.Sprintf("(%s)\n", yPositive) +
+		"@SP\n" +
+		"A=M-1\n" +
+		"D=M\n" +
+		"@R13\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", xPositive) +
+		"D;JGE\n" +
+		"@R13\n" +
+		"M=-M\n" +
+		fmt.Sprintf("@%s\n", negative) +
+		"M=!M\n" +
+		fmt.Sprintf("(%s)\n", xPositive) +
+		fmt.Sprintf("@%s\n", remainder) +
+		"M=0\n" +
+		fmt.Sprintf("@%s\n", quotient) +
+		"M=0\n" +
+		"@16\n" +
+		"D=A\n" +
+		fmt.Sprintf("@%s\n", bits) +
+		"M=D\n")
+	c.writeCommand("// shift the top bit of |x| into the remainder\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", loop) +
+		fmt.Sprintf("@%s\n", quotient) +
+		"D=M\n" +
+		"M=D+M\n" +
+		fmt.Sprintf("@%s\n", remainder) +
+		"D=M\n" +
+		"M=D+M\n" +
+		"@R13\n" +
+		"D=M\n" +
+		"M=D+M\n" +
+		fmt.Sprintf("@%s\n", shifted) +
+		"D;JGE\n" +
+		fmt.Sprintf("@%s\n", remainder) +
+		"M=M+1\n" +
+		fmt.Sprintf("(%s)\n", shifted))
+	c.writeCommand("// subtract |y| if remainder >= |y| unsigned, |y| <= 32768 so only\n" +
+		"// |y| = 32768 and remainders >= 32768 have the top bit set\n")
+	c.writeCommand("@R14\n" +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", bigY) +
+		"D;JLT\n" +
+		fmt.Sprintf("@%s\n", remainder) +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", subtract) +
+		"D;JLT\n" +
+		"@R14\n" +
+		"D=D-M\n" +
+		fmt.Sprintf("@%s\n", next) +
+		"D;JLT\n" +
+		fmt.Sprintf("@%s\n", subtract) +
+		"0;JMP\n" +
+		fmt.Sprintf("(%s)\n", bigY) +
+		fmt.Sprintf("@%s\n", remainder) +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", next) +
+		"D;JGE\n" +
+		fmt.Sprintf("(%s)\n", subtract) +
+		"@R14\n" +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", remainder) +
+		"M=M-D\n" +
+		fmt.Sprintf("@%s\n", quotient) +
+		"M=M+1\n" +
+		fmt.Sprintf("(%s)\n", next) +
+		fmt.Sprintf("@%s\n", bits) +
+		"MD=M-1\n" +
+		fmt.Sprintf("@%s\n", loop) +
+		"D;JGT\n")
+	c.writeCommand(fmt.Sprintf("@%s\n", negative) +
+		"D=M\n" +
+		fmt.Sprintf("@%s\n", positive) +
+		"D;JEQ\n" +
+		fmt.Sprintf("@%s\n", quotient) +
+		"M=-M\n" +
+		fmt.Sprintf("(%s)\n", positive) +
+		fmt.Sprintf("@%s\n", quotient) +
+		"D=M\n" +
+		"@SP\n" +
+		"A=M-1\n" +
+		"M=D\n" +
+		"@R15\n" +
+		"A=M\n" +
+		"0;JMP\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", byZero) +
+		fmt.Sprintf("@%s\n", byZero) +
+		"0;JMP\n")
+}
@@ -0,0 +1,42 @@
+package vmtranslator
+
+// token is a word of a vm command, column starts at 1
+type token struct {
+	text   string
+	column int
+}
+
+// lexLine splits a line of vm source into tokens, it drops `//` comments
+// and treats spaces, tabs and carriage returns as separators so indented
+// lines, trailing comments and windows line endings all work
+func lexLine(line string) []token {
+	var tokens []token
+	start := -1
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if ch == '/' && i+1 < len(line) && line[i+1] == '/' {
+			if start >= 0 {
+				tokens = append(tokens, token{line[start:i], start + 1})
+			}
+			return tokens
+		}
+		if isSpace(ch) {
+			if start >= 0 {
+				tokens = append(tokens, token{line[start:i], start + 1})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{line[start:], start + 1})
+	}
+	return tokens
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\v' || ch == '\f'
+}
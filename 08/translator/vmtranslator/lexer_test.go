@@ -0,0 +1,144 @@
+package vmtranslator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLexLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []token
+	}{
+		{"empty", "", nil},
+		{"blank", " \t\r", nil},
+		{"comment", "// push constant 7", nil},
+		{"command", "push constant 7", []token{{"push", 1}, {"constant", 6}, {"7", 15}}},
+		{"tabs", "\tpush\tlocal\t2", []token{{"push", 2}, {"local", 7}, {"2", 13}}},
+		{"crlf", "add\r", []token{{"add", 1}}},
+		{"crlf after argument", "pop temp 6\r", []token{{"pop", 1}, {"temp", 5}, {"6", 10}}},
+		{"double spaces", "push  argument   1", []token{{"push", 1}, {"argument", 7}, {"1", 18}}},
+		{"trailing comment", "goto LOOP // again", []token{{"goto", 1}, {"LOOP", 6}}},
+		{"comment without space", "label END//done", []token{{"label", 1}, {"END", 7}}},
+		{"comment after crlf", "return\r// x", []token{{"return", 1}}},
+		{"single slash", "label a/b", []token{{"label", 1}, {"a/b", 7}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := lexLine(test.line); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("lexLine(%q) = %v, want %v", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Command
+	}{
+		{
+			name: "tabs",
+			src:  "\tpush\tconstant\t7\n",
+			want: []Command{{Type: C_PUSH, Arg1: "constant", Arg2: 7,
+				Pos: Position{"T.vm", 1, 2}, Arg1Pos: Position{"T.vm", 1, 7}, Arg2Pos: Position{"T.vm", 1, 16}}},
+		},
+		{
+			name: "crlf",
+			src:  "push local 0\r\nadd\r\n",
+			want: []Command{
+				{Type: C_PUSH, Arg1: "local", Arg2: 0,
+					Pos: Position{"T.vm", 1, 1}, Arg1Pos: Position{"T.vm", 1, 6}, Arg2Pos: Position{"T.vm", 1, 12}},
+				{Type: C_ARITHMETIC, Arg1: "add", Arg2: -1,
+					Pos: Position{"T.vm", 2, 1}, Arg1Pos: Position{"T.vm", 2, 1}, Arg2Pos: Position{"T.vm", 2, 4}},
+			},
+		},
+		{
+			name: "double spaces",
+			src:  "call  Main.main  0\n",
+			want: []Command{{Type: C_CALL, Arg1: "Main.main", Arg2: 0,
+				Pos: Position{"T.vm", 1, 1}, Arg1Pos: Position{"T.vm", 1, 7}, Arg2Pos: Position{"T.vm", 1, 18}}},
+		},
+		{
+			name: "trailing comments",
+			src:  "// header\n\nfunction Main.main 2 // two locals\nif-goto END// done\n",
+			want: []Command{
+				{Type: C_FUNCTION, Arg1: "Main.main", Arg2: 2,
+					Pos: Position{"T.vm", 3, 1}, Arg1Pos: Position{"T.vm", 3, 10}, Arg2Pos: Position{"T.vm", 3, 20}},
+				{Type: C_IF, Arg1: "END", Arg2: -1,
+					Pos: Position{"T.vm", 4, 1}, Arg1Pos: Position{"T.vm", 4, 9}, Arg2Pos: Position{"T.vm", 4, 12}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(test.src), "T.vm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range got {
+				got[i].Line = ""
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse(%q) =\n%+v\nwant\n%+v", test.src, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"tabs before extra argument", "add\t\t1\n", "T.vm:1:6: unexpected argument: \"1\""},
+		{"crlf after bad index", "push constant x\r\n", "T.vm:1:15: argument is not a number: \"x\""},
+		{"missing argument before comment", "goto  // LOOP\n", "T.vm:1:5: goto needs 1 argument(s)"},
+		{"unknown command after comment line", "// c\n  jump 3 // x\n", "T.vm:2:3: unknown command: \"jump\""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.src), "T.vm")
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Parse(%q) error = %v, want %s", test.src, err, test.want)
+			}
+		})
+	}
+}
+
+// TestParseSamples parses every vm file of projects 07 and 08
+func TestParseSamples(t *testing.T) {
+	var paths []string
+	for _, pattern := range []string{"../../../07/*/*/*.vm", "../../*/*/*.vm"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no vm files found")
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			commands, err := Parse(file, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(commands) == 0 {
+				t.Fatal("no commands")
+			}
+		})
+	}
+}
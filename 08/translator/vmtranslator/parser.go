@@ -0,0 +1,174 @@
+package vmtranslator
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parse reads the vm commands from r, name is used as the file in the
+// positions of the commands and errors. Lines that can't be parsed are left
+// out and reported together in the returned DiagnosticList.
+func Parse(r io.Reader, name string) ([]Command, error) {
+	parser := newParser(r, name)
+	var commands []Command
+	var diagnostics DiagnosticList
+	for parser.advance() {
+		cmd, err := parser.commandType()
+		if err != nil {
+			diagnostics.Add(err)
+			continue
+		}
+		arg2, err := parser.arg2(cmd)
+		if err != nil {
+			diagnostics.Add(err)
+			continue
+		}
+		commands = append(commands, Command{
+			Type:    cmd,
+			Arg1:    parser.arg1(cmd),
+			Arg2:    arg2,
+			Line:    strings.TrimSpace(parser.getLine()),
+			Pos:     parser.tokenPosition(0),
+			Arg1Pos: parser.arg1Position(cmd),
+			Arg2Pos: parser.tokenPosition(2),
+		})
+	}
+	if err := parser.scanner.Err(); err != nil {
+		diagnostics.Add(newDiagnostic(Position{File: name}, "", "%s", err))
+	}
+	return commands, diagnostics.Err()
+}
+
+type parser struct {
+	scanner *bufio.Scanner
+	name    string
+	lineNo  int
+	tokens  []token
+}
+
+func newParser(r io.Reader, name string) *parser {
+	return &parser{scanner: bufio.NewScanner(r), name: name}
+}
+
+func (p *parser) advance() bool {
+	for {
+		ok := p.scanner.Scan()
+		if !ok {
+			return false
+		}
+		p.lineNo++
+		p.tokens = lexLine(p.scanner.Text())
+		if len(p.tokens) == 0 {
+			continue
+		}
+		return true
+	}
+}
+
+func (p *parser) getLine() string {
+	return p.scanner.Text()
+}
+
+// tokenPosition returns the position of the i-th token on the current line,
+// or the end of the line if there are not that many tokens
+func (p *parser) tokenPosition(i int) Position {
+	if i < len(p.tokens) {
+		return Position{p.name, p.lineNo, p.tokens[i].column}
+	}
+	last := p.tokens[len(p.tokens)-1]
+	return Position{p.name, p.lineNo, last.column + len(last.text)}
+}
+
+// arg1Position returns the position of the token arg1 returns
+func (p *parser) arg1Position(cmd CommandType) Position {
+	if cmd == C_ARITHMETIC {
+		return p.tokenPosition(0)
+	}
+	return p.tokenPosition(1)
+}
+
+// errorf returns a diagnostic pointing at the i-th token on the current line
+func (p *parser) errorf(i int, format string, args ...interface{}) error {
+	text := ""
+	if i < len(p.tokens) {
+		text = p.tokens[i].text
+	}
+	return newDiagnostic(p.tokenPosition(i), text, format, args...)
+}
+
+// number of arguments each command takes
+var commandArgs = map[CommandType]int{
+	C_ARITHMETIC: 0,
+	C_PUSH:       2,
+	C_POP:        2,
+	C_LABEL:      1,
+	C_GOTO:       1,
+	C_IF:         1,
+	C_FUNCTION:   2,
+	C_RETURN:     0,
+	C_CALL:       2,
+}
+
+func (p *parser) commandType() (CommandType, error) {
+	cmd := p.tokens[0].text
+	var cmdType CommandType
+	if cmd == "add" || cmd == "sub" || cmd == "neg" || cmd == "eq" || cmd == "gt" || cmd == "lt" || cmd == "and" || cmd == "or" || cmd == "not" {
+		cmdType = C_ARITHMETIC
+	} else if cmd == "push" {
+		cmdType = C_PUSH
+	} else if cmd == "pop" {
+		cmdType = C_POP
+	} else if cmd == "label" {
+		cmdType = C_LABEL
+	} else if cmd == "goto" {
+		cmdType = C_GOTO
+	} else if cmd == "if-goto" {
+		cmdType = C_IF
+	} else if cmd == "function" {
+		cmdType = C_FUNCTION
+	} else if cmd == "call" {
+		cmdType = C_CALL
+	} else if cmd == "return" {
+		cmdType = C_RETURN
+	} else {
+		return "", p.errorf(0, "unknown command")
+	}
+	nArgs := commandArgs[cmdType]
+	if len(p.tokens) < nArgs+1 {
+		return "", p.errorf(len(p.tokens), "%s needs %d argument(s)", cmd, nArgs)
+	}
+	if len(p.tokens) > nArgs+1 {
+		return "", p.errorf(nArgs+1, "unexpected argument")
+	}
+	return cmdType, nil
+}
+
+func (p *parser) arg1(cmd CommandType) string {
+	if cmd == C_ARITHMETIC {
+		return p.tokens[0].text
+	} else {
+		if len(p.tokens) > 1 {
+			return p.tokens[1].text
+		} else {
+			return ""
+		}
+	}
+}
+
+func (p *parser) arg2(cmd CommandType) (int, error) {
+	if cmd == C_PUSH || cmd == C_POP || cmd == C_FUNCTION || cmd == C_CALL {
+		if len(p.tokens) < 3 {
+			return -1, p.errorf(len(p.tokens), "not enough arguments")
+		}
+		argStr := p.tokens[2].text
+		arg, err := strconv.Atoi(argStr)
+		if err != nil {
+			return -1, p.errorf(2, "argument is not a number")
+		}
+		return arg, nil
+	} else {
+		return -1, nil
+	}
+}
@@ -0,0 +1,129 @@
+package vmtranslator
+
+import "strings"
+
+// asmLine is a line of generated assembly: an instruction, a label, a
+// comment or an empty line
+type asmLine string
+
+func (l asmLine) isLabel() bool {
+	return strings.HasPrefix(string(l), "(")
+}
+
+func (l asmLine) isInstruction() bool {
+	return l != "" && !l.isLabel() && !strings.HasPrefix(string(l), "//")
+}
+
+func (l asmLine) isAInstruction() bool {
+	return strings.HasPrefix(string(l), "@")
+}
+
+// isPlainJump reports whether l is a C-instruction that jumps without
+// writing any register or memory, e.g. 0;JMP or D;JNE
+func (l asmLine) isPlainJump() bool {
+	return l.isInstruction() && !l.isAInstruction() &&
+		strings.Contains(string(l), ";") && !strings.Contains(string(l), "=")
+}
+
+// countInstructions returns the number of ROM words lines assemble to
+func countInstructions(lines []asmLine) int {
+	n := 0
+	for _, l := range lines {
+		if l.isInstruction() {
+			n++
+		}
+	}
+	return n
+}
+
+// optimize runs the peephole rules over lines until none of them applies
+// anymore. Comments stay in place so the output can still be read along
+// the vm commands. origins holds the source of each line and is filtered
+// along with it.
+func optimize(lines []asmLine, origins []int) ([]asmLine, []int) {
+	rules := []func(lines []asmLine, code []int, removed []bool){
+		removeStackPairs,
+		removeDeadLoads,
+		removeJumpsToNext,
+	}
+	for {
+		changed := false
+		for _, rule := range rules {
+			// code holds the indexes of the labels and instructions, so
+			// consecutive entries have nothing but comments between them
+			var code []int
+			for i, l := range lines {
+				if l.isInstruction() || l.isLabel() {
+					code = append(code, i)
+				}
+			}
+			removed := make([]bool, len(lines))
+			rule(lines, code, removed)
+			kept := lines[:0:0]
+			keptOrigins := origins[:0:0]
+			for i, l := range lines {
+				if !removed[i] {
+					kept = append(kept, l)
+					keptOrigins = append(keptOrigins, origins[i])
+				}
+			}
+			if len(kept) != len(lines) {
+				changed = true
+			}
+			lines = kept
+			origins = keptOrigins
+		}
+		if !changed {
+			return lines, origins
+		}
+	}
+}
+
+// removeStackPairs drops an SP increment that is undone right away:
+// `@SP M=M+1 @SP M=M-1` is the end of a push followed by the start of a
+// pop and becomes `@SP`
+func removeStackPairs(lines []asmLine, code []int, removed []bool) {
+	for p := 0; p+3 < len(code); p++ {
+		if lines[code[p]] == "@SP" && lines[code[p+1]] == "M=M+1" &&
+			lines[code[p+2]] == "@SP" && lines[code[p+3]] == "M=M-1" {
+			removed[code[p+1]] = true
+			removed[code[p+2]] = true
+			removed[code[p+3]] = true
+			p += 3
+		}
+	}
+}
+
+// removeDeadLoads drops an A-instruction whose value is replaced by the
+// next A-instruction before it is used
+func removeDeadLoads(lines []asmLine, code []int, removed []bool) {
+	for p := 0; p+1 < len(code); p++ {
+		if lines[code[p]].isAInstruction() && lines[code[p+1]].isAInstruction() {
+			removed[code[p]] = true
+		}
+	}
+}
+
+// removeJumpsToNext drops `@L D;JNE` style jumps to a label that directly
+// follows them. A jump always arrives with A set to the label address, so
+// this is only done if the code after the label sets A itself first.
+func removeJumpsToNext(lines []asmLine, code []int, removed []bool) {
+	for p := 0; p+2 < len(code); p++ {
+		if !lines[code[p]].isAInstruction() || !lines[code[p+1]].isPlainJump() {
+			continue
+		}
+		target := "(" + string(lines[code[p]][1:]) + ")"
+		found := false
+		q := p + 2
+		for ; q < len(code) && lines[code[q]].isLabel(); q++ {
+			if string(lines[code[q]]) == target {
+				found = true
+			}
+		}
+		if found && q < len(code) && lines[code[q]].isAInstruction() {
+			removed[code[p]] = true
+			removed[code[p+1]] = true
+			p++
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package vmtranslator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// asmLines splits assembly into lines
+func asmLines(src string) []asmLine {
+	var lines []asmLine
+	for _, l := range strings.Split(strings.TrimSuffix(src, "\n"), "\n") {
+		lines = append(lines, asmLine(l))
+	}
+	return lines
+}
+
+// applyRule runs one rule over the assembly once, like optimize does
+func applyRule(rule func(lines []asmLine, code []int, removed []bool), src string) string {
+	lines := asmLines(src)
+	var code []int
+	for i, l := range lines {
+		if l.isInstruction() || l.isLabel() {
+			code = append(code, i)
+		}
+	}
+	removed := make([]bool, len(lines))
+	rule(lines, code, removed)
+	var b strings.Builder
+	for i, l := range lines {
+		if !removed[i] {
+			b.WriteString(string(l) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestPeepholeRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule func(lines []asmLine, code []int, removed []bool)
+		in   string
+		want string
+	}{
+		{
+			name: "stack pair",
+			rule: removeStackPairs,
+			in:   "M=D\n@SP\nM=M+1\n// pop local 0\n@SP\nM=M-1\nA=M\n",
+			want: "M=D\n@SP\n// pop local 0\nA=M\n",
+		},
+		{
+			name: "stack pair with a label between",
+			rule: removeStackPairs,
+			in:   "@SP\nM=M+1\n(L)\n@SP\nM=M-1\n",
+			want: "@SP\nM=M+1\n(L)\n@SP\nM=M-1\n",
+		},
+		{
+			name: "stack pair in the other order",
+			rule: removeStackPairs,
+			in:   "@SP\nM=M-1\n@SP\nM=M+1\n",
+			want: "@SP\nM=M-1\n@SP\nM=M+1\n",
+		},
+		{
+			name: "dead load",
+			rule: removeDeadLoads,
+			in:   "@SP\n// comment\n@LCL\nD=M\n",
+			want: "// comment\n@LCL\nD=M\n",
+		},
+		{
+			name: "dead loads in a row",
+			rule: removeDeadLoads,
+			in:   "@1\n@2\n@3\nD=A\n",
+			want: "@3\nD=A\n",
+		},
+		{
+			name: "label between two loads",
+			rule: removeDeadLoads,
+			in:   "@SP\n(L)\n@LCL\nD=M\n",
+			want: "@SP\n(L)\n@LCL\nD=M\n",
+		},
+		{
+			name: "load used in between",
+			rule: removeDeadLoads,
+			in:   "@SP\nD=M\n@LCL\n",
+			want: "@SP\nD=M\n@LCL\n",
+		},
+		{
+			name: "jump to next",
+			rule: removeJumpsToNext,
+			in:   "D=M\n@L\nD;JNE\n(L)\n@SP\n",
+			want: "D=M\n(L)\n@SP\n",
+		},
+		{
+			name: "jump past other labels to next",
+			rule: removeJumpsToNext,
+			in:   "@L\n0;JMP\n(K)\n// c\n(L)\n@SP\n",
+			want: "(K)\n// c\n(L)\n@SP\n",
+		},
+		{
+			name: "jump to a label that isn't next",
+			rule: removeJumpsToNext,
+			in:   "@L\n0;JMP\n@SP\n(L)\n@SP\n",
+			want: "@L\n0;JMP\n@SP\n(L)\n@SP\n",
+		},
+		{
+			name: "jump to next that needs A",
+			rule: removeJumpsToNext,
+			in:   "@L\n0;JMP\n(L)\nD=A\n",
+			want: "@L\n0;JMP\n(L)\nD=A\n",
+		},
+		{
+			name: "jump that writes a register",
+			rule: removeJumpsToNext,
+			in:   "@L\nD=M;JNE\n(L)\n@SP\n",
+			want: "@L\nD=M;JNE\n(L)\n@SP\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := applyRule(test.rule, test.in); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// TestOptimize checks that the rules are applied until none applies and
+// the origins are filtered along with the lines
+func TestOptimize(t *testing.T) {
+	// removing the stack pair leaves two loads, removing the dead load
+	// leaves a jump to the next label
+	lines := asmLines("@SP\nM=M+1\n@SP\nM=M-1\n@L\n0;JMP\n(L)\n@R13\nM=D\n")
+	origins := []int{0, 0, 1, 1, 2, 2, 2, 3, 3}
+	gotLines, gotOrigins := optimize(lines, origins)
+	if want := asmLines("(L)\n@R13\nM=D\n"); !reflect.DeepEqual(gotLines, want) {
+		t.Errorf("lines: got %q, want %q", gotLines, want)
+	}
+	if want := []int{2, 3, 3}; !reflect.DeepEqual(gotOrigins, want) {
+		t.Errorf("origins: got %v, want %v", gotOrigins, want)
+	}
+	if n := countInstructions(gotLines); n != 2 {
+		t.Errorf("countInstructions: got %d, want 2", n)
+	}
+}
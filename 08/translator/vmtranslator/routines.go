@@ -0,0 +1,211 @@
+package vmtranslator
+
+import "fmt"
+
+// Shared routines replace the inlined call, return and comparison code with
+// a jump to one copy of it per program, they are written after the program
+// by writeRoutines. Parameters are passed in D and R13-R15.
+const (
+	callRoutine    = "$$CALL"
+	returnRoutine  = "$$RETURN"
+	compareRoutine = "$$COMPARE"
+	// programEnd is the loop that stops programs without bootstrap code
+	// from running into the routines
+	programEnd = "$$END"
+)
+
+// compareMasks select the outcomes of x-y that make a comparison true,
+// using the bits of the jump field: 4 negative, 2 zero, 1 positive
+var compareMasks = map[string]int{
+	"eq": 2,
+	"gt": 1,
+	"lt": 4,
+}
+
+// sharedCompare returns the code that calls $$COMPARE with the outcome mask
+// in R14 and the return address in R15
+func (c *codeWriter) sharedCompare(cmd string) string {
+	c.usedRoutines[compareRoutine] = true
+	returnAddress := fmt.Sprintf("CMP_RET%d", c.cmdCount)
+	return fmt.Sprintf("@%d\n", compareMasks[cmd]) +
+		"D=A\n" +
+		"@R14\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", returnAddress) +
+		"D=A\n" +
+		"@R15\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", compareRoutine) +
+		"0;JMP\n" +
+		fmt.Sprintf("(%s)\n", returnAddress)
+}
+
+// writeSharedCall calls $$CALL with the number of arguments in R13, the
+// function address in R14 and the return address in D
+func (c *codeWriter) writeSharedCall(functionName string, numArgs int) {
+	c.usedRoutines[callRoutine] = true
+	returnAddress := fmt.Sprintf("%s:%d:%d", functionName, numArgs, c.cmdCount)
+	c.writeCommand(fmt.Sprintf("// call %s %d\n", functionName, numArgs))
+	c.writeCommand(fmt.Sprintf("@%d\n", numArgs) +
+		"D=A\n" +
+		"@R13\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", functionName) +
+		"D=A\n" +
+		"@R14\n" +
+		"M=D\n" +
+		fmt.Sprintf("@%s\n", returnAddress) +
+		"D=A\n" +
+		fmt.Sprintf("@%s\n", callRoutine) +
+		"0;JMP\n" +
+		fmt.Sprintf("(%s)\n", returnAddress))
+}
+
+func (c *codeWriter) writeSharedReturn() {
+	c.usedRoutines[returnRoutine] = true
+	c.writeCommand("// return\n")
+	c.writeCommand(fmt.Sprintf("@%s\n", returnRoutine) +
+		"0;JMP\n")
+}
+
+// writeRoutines writes the shared routines the program uses
+func (c *codeWriter) writeRoutines() {
+	if len(c.usedRoutines) == 0 {
+		return
+	}
+	c.setSource(SourceEntry{Function: programEnd})
+	c.writeCommand("// ** shared routines **\n")
+	c.writeCommand(fmt.Sprintf("(%s)\n", programEnd) +
+		fmt.Sprintf("@%s\n", programEnd) +
+		"0;JMP\n")
+
+	pushDToStack := "@SP\n" +
+		"A=M\n" +
+		"M=D\n" +
+		"@SP\n" +
+		"M=M+1\n"
+	pushPointerToStack := "@%s\n" +
+		"D=M\n" +
+		pushDToStack
+
+	if c.usedRoutines[callRoutine] {
+		c.setSource(SourceEntry{Function: callRoutine})
+		c.writeCommand("// ** call: R13 = n, R14 = f, D = return-address **\n")
+		c.writeCommand(fmt.Sprintf("(%s)\n", callRoutine))
+		c.writeCommand(pushDToStack)
+		for _, pointer := range []string{"LCL", "ARG", "THIS", "THAT"} {
+			c.writeCommand(fmt.Sprintf(pushPointerToStack, pointer))
+		}
+		c.writeCommand("// ARG = SP - n - 5\n")
+		c.writeCommand("@SP\n" +
+			"D=M\n" +
+			"@5\n" +
+			"D=D-A\n" +
+			"@R13\n" +
+			"D=D-M\n" +
+			"@ARG\n" +
+			"M=D\n")
+		c.writeCommand("// LCL = SP\n")
+		c.writeCommand("@SP\n" +
+			"D=M\n" +
+			"@LCL\n" +
+			"M=D\n")
+		c.writeCommand("// goto f\n")
+		c.writeCommand("@R14\n" +
+			"A=M\n" +
+			"0;JMP\n")
+	}
+
+	if c.usedRoutines[returnRoutine] {
+		c.setSource(SourceEntry{Function: returnRoutine})
+		c.writeCommand("// ** return **\n")
+		c.writeCommand(fmt.Sprintf("(%s)\n", returnRoutine))
+		c.writeReturnBody()
+	}
+
+	if c.usedRoutines[compareRoutine] {
+		c.setSource(SourceEntry{Function: compareRoutine})
+		yNeg := compareRoutine + "_YNEG"
+		sub := compareRoutine + "_SUB"
+		sign := compareRoutine + "_SIGN"
+		lt := compareRoutine + "_LT"
+		gt := compareRoutine + "_GT"
+		test := compareRoutine + "_TEST"
+		end := compareRoutine + "_END"
+		c.writeCommand("// ** compare: R14 = outcome mask, R15 = return-address **\n")
+		c.writeCommand(fmt.Sprintf("(%s)\n", compareRoutine) +
+			"@SP\n" +
+			"AM=M-1\n" +
+			"D=M\n")
+		if c.fastCompare {
+			c.writeCommand("A=A-1\n" +
+				"D=M-D\n")
+		} else {
+			// D gets the sign of x-y, which is only subtracted if x and y
+			// have the same sign so it can't overflow
+			c.writeCommand(fmt.Sprintf("@%s\n", yNeg) +
+				"D;JLT\n" +
+				"@SP\n" +
+				"A=M-1\n" +
+				"D=M\n" +
+				fmt.Sprintf("@%s\n", sub) +
+				"D;JGE\n" +
+				"D=-1\n" +
+				fmt.Sprintf("@%s\n", sign) +
+				"0;JMP\n" +
+				fmt.Sprintf("(%s)\n", yNeg) +
+				"@SP\n" +
+				"A=M-1\n" +
+				"D=M\n" +
+				fmt.Sprintf("@%s\n", sub) +
+				"D;JLT\n" +
+				"D=1\n" +
+				fmt.Sprintf("@%s\n", sign) +
+				"0;JMP\n" +
+				fmt.Sprintf("(%s)\n", sub) +
+				"@SP\n" +
+				"A=M\n" +
+				"D=M\n" +
+				"A=A-1\n" +
+				"D=M-D\n" +
+				fmt.Sprintf("(%s)\n", sign))
+		}
+		c.writeCommand(fmt.Sprintf("@%s\n", lt) +
+			"D;JLT\n" +
+			fmt.Sprintf("@%s\n", gt) +
+			"D;JGT\n" +
+			"@2\n" +
+			"D=A\n" +
+			fmt.Sprintf("@%s\n", test) +
+			"0;JMP\n" +
+			fmt.Sprintf("(%s)\n", lt) +
+			"@4\n" +
+			"D=A\n" +
+			fmt.Sprintf("@%s\n", test) +
+			"0;JMP\n" +
+			fmt.Sprintf("(%s)\n", gt) +
+			"D=1\n" +
+			fmt.Sprintf("(%s)\n", test) +
+			"@R14\n" +
+			"D=D&M\n" +
+			"@SP\n" +
+			"A=M-1\n" +
+			"M=0\n" +
+			fmt.Sprintf("@%s\n", end) +
+			"D;JEQ\n" +
+			"@SP\n" +
+			"A=M-1\n" +
+			"M=-1\n" +
+			fmt.Sprintf("(%s)\n", end) +
+			"@R15\n" +
+			"A=M\n" +
+			"0;JMP\n")
+	}
+
+	if c.usedRoutines[multiplyRoutine] {
+		c.writeMultiply()
+	}
+	if c.usedRoutines[divideRoutine] {
+		c.writeDivide()
+	}
+}
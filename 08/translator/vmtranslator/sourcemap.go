@@ -0,0 +1,52 @@
+package vmtranslator
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SourceEntry is where the code at a ROM address comes from. File and Line
+// are empty for code that isn't written for a vm command: Command is
+// "bootstrap" for the bootstrap code and Function is the name of the shared
+// routine for those.
+type SourceEntry struct {
+	Address  int    `json:"address"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Command  string `json:"command,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// SourceMap has one entry per ROM address of a translated program
+type SourceMap []SourceEntry
+
+// buildSourceMap returns the origin of every instruction in c.lines
+func (c *codeWriter) buildSourceMap() SourceMap {
+	var m SourceMap
+	for i, l := range c.lines {
+		if !l.isInstruction() {
+			continue
+		}
+		var entry SourceEntry
+		if c.origins[i] >= 0 {
+			entry = c.sources[c.origins[i]]
+		}
+		entry.Address = len(m)
+		m = append(m, entry)
+	}
+	return m
+}
+
+// WriteJSON writes the map as a JSON object with the name of the program
+// and the entries by address
+func (m SourceMap) WriteJSON(w io.Writer, program string) error {
+	if m == nil {
+		m = SourceMap{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Program   string        `json:"program"`
+		Addresses []SourceEntry `json:"addresses"`
+	}{program, m})
+}
@@ -0,0 +1,89 @@
+package vmtranslator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"translator/assembler"
+)
+
+var sourceMapSources = map[string]string{
+	"Sys": "function Sys.init 0\ncall Main.main 0\nlabel END\ngoto END\n",
+	"Main": "function Main.main 1\npush constant 7\npush constant 8\ngt\npop local 0\n" +
+		"push local 0\nreturn\n",
+}
+
+// romAddress returns the ROM address of the first instruction after the
+// line marker in asm, which is a comment or a label
+func romAddress(asm, marker string) int {
+	address := 0
+	for _, line := range strings.Split(asm, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == marker:
+			return address
+		case line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "("):
+		default:
+			address++
+		}
+	}
+	return -1
+}
+
+func TestSourceMap(t *testing.T) {
+	modes := map[string]Options{
+		"default":          {},
+		"-O":               {Optimize: true},
+		"-shared-routines": {SharedRoutines: true},
+	}
+	for mode, opts := range modes {
+		t.Run(mode, func(t *testing.T) {
+			var m SourceMap
+			opts.SourceMap = &m
+			var asm bytes.Buffer
+			if err := Translate(parseModules(t, sourceMapSources), &asm, opts); err != nil {
+				t.Fatal(err)
+			}
+			code, err := assembler.Assemble(bytes.NewReader(asm.Bytes()), "Main.asm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m) != len(code) {
+				t.Fatalf("%d entries for %d ROM words", len(m), len(code))
+			}
+			for i, entry := range m {
+				if entry.Address != i {
+					t.Fatalf("entry %d has address %d", i, entry.Address)
+				}
+			}
+
+			// @256 D=A @SP M=D
+			for i := 0; i < 4; i++ {
+				if want := (SourceEntry{Address: i, Command: "bootstrap"}); m[i] != want {
+					t.Errorf("got %+v, want %+v", m[i], want)
+				}
+			}
+
+			push := romAddress(asm.String(), "// push constant 7")
+			if push < 0 {
+				t.Fatal("no push constant 7 in the translation")
+			}
+			want := SourceEntry{Address: push, File: "Main.vm", Line: 2, Command: "push constant 7", Function: "Main.main"}
+			if m[push] != want {
+				t.Errorf("got %+v, want %+v", m[push], want)
+			}
+
+			if opts.SharedRoutines {
+				call := romAddress(asm.String(), "("+callRoutine+")")
+				if call < 0 {
+					t.Fatal("no call routine in the translation")
+				}
+				want := SourceEntry{Address: call, Function: callRoutine}
+				if m[call] != want {
+					t.Errorf("got %+v, want %+v", m[call], want)
+				}
+			}
+		})
+	}
+}
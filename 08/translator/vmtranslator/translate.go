@@ -0,0 +1,228 @@
+package vmtranslator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Bootstrap selects when Translate writes the code that sets SP and calls
+// Sys.init
+type Bootstrap string
+
+const (
+	// BootstrapAuto writes the bootstrap only if a module defines Sys.init
+	BootstrapAuto   Bootstrap = "auto"
+	BootstrapAlways Bootstrap = "always"
+	BootstrapNever  Bootstrap = "never"
+)
+
+// Profile selects which part of the vm language is translated
+type Profile string
+
+const (
+	// ProfileStage1 is project 07: arithmetic and memory access commands
+	// only, without bootstrap code
+	ProfileStage1 Profile = "stage1"
+	// ProfileFull is project 08: the whole vm language
+	ProfileFull Profile = "full"
+)
+
+// commands allowed by ProfileStage1
+var stage1Commands = map[CommandType]bool{
+	C_ARITHMETIC: true,
+	C_PUSH:       true,
+	C_POP:        true,
+}
+
+// Options configure Translate, the zero value is ProfileFull with
+// BootstrapAuto
+type Options struct {
+	Profile   Profile
+	Bootstrap Bootstrap
+	// Optimize runs the peephole optimizer over the generated assembly
+	Optimize bool
+	// OptimizeVM runs OptimizeVM over the modules before translating them
+	OptimizeVM bool
+	// RemoveDeadFunctions leaves out the functions Sys.init can't reach, if
+	// the bootstrap code is written
+	RemoveDeadFunctions bool
+	// SharedRoutines writes call, return, eq, gt and lt as jumps to one
+	// shared routine each instead of inlining them, which saves ROM
+	SharedRoutines bool
+	// FastCompare translates gt and lt by checking the sign of x-y only,
+	// which is shorter but wrong when x and y have different signs and
+	// the subtraction overflows, e.g. 32767 gt -1
+	FastCompare bool
+	// Intrinsics replaces the calls of Math.multiply, Math.divide,
+	// Memory.peek and Memory.poke with code of the translator, see
+	// IsIntrinsic. The functions don't have to be defined then.
+	Intrinsics bool
+	// Stats is filled with the instruction counts if it is not nil
+	Stats *Stats
+	// SourceMap is filled with the origin of every ROM word if it is not nil
+	SourceMap *SourceMap
+}
+
+// Stats are the ROM words of a translated program without and with the
+// optimizers, Optimized equals Instructions if they are off
+type Stats struct {
+	Instructions int
+	Optimized    int
+	// Removed are the functions RemoveDeadFunctions left out
+	Removed []RemovedFunction
+}
+
+// Translate validates the modules and writes them to w as one Hack assembly
+// program. All problems found are returned together as a DiagnosticList.
+func Translate(modules []Module, w io.Writer, opts Options) error {
+	switch opts.Profile {
+	case ProfileFull, "":
+	case ProfileStage1:
+		if err := checkStage1(modules); err != nil {
+			return err
+		}
+		opts.Bootstrap = BootstrapNever
+	default:
+		return fmt.Errorf("unknown profile: %s", opts.Profile)
+	}
+	if opts.Intrinsics {
+		modules = replaceIntrinsics(modules)
+	}
+	if err := Validate(modules); err != nil {
+		return err
+	}
+
+	var writeInit bool
+	switch opts.Bootstrap {
+	case BootstrapAuto, "":
+		writeInit = definesFunction(modules, "Sys.init")
+	case BootstrapAlways:
+		if !definesFunction(modules, "Sys.init") {
+			return newDiagnostic(Position{}, "", "the bootstrap code calls Sys.init, but no module defines it")
+		}
+		writeInit = true
+	case BootstrapNever:
+		writeInit = false
+	default:
+		return fmt.Errorf("unknown bootstrap mode: %s", opts.Bootstrap)
+	}
+
+	if opts.RemoveDeadFunctions && writeInit {
+		var removed []RemovedFunction
+		modules, removed = RemoveDeadFunctions(modules, "Sys.init")
+		if opts.Stats != nil {
+			for i := range removed {
+				removed[i].Instructions = functionInstructions(removed[i].Commands, opts)
+			}
+			opts.Stats.Removed = removed
+		}
+	}
+
+	instructions := -1
+	if opts.OptimizeVM {
+		if opts.Stats != nil {
+			// translate the modules as they are for the count without
+			// optimizations
+			var stats Stats
+			unoptimized := Options{Profile: opts.Profile, Bootstrap: opts.Bootstrap,
+				SharedRoutines: opts.SharedRoutines, FastCompare: opts.FastCompare, Intrinsics: opts.Intrinsics,
+				Stats: &stats}
+			if err := Translate(modules, io.Discard, unoptimized); err != nil {
+				return err
+			}
+			instructions = stats.Instructions
+		}
+		modules = OptimizeVM(modules)
+	}
+
+	var diagnostics DiagnosticList
+	codeWriter := newCodeWriter(w, opts)
+	if writeInit {
+		codeWriter.writeInit()
+	}
+	for _, module := range modules {
+		diagnostics.Add(codeWriter.writeModule(module))
+	}
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+	if err := codeWriter.close(); err != nil {
+		return err
+	}
+	if opts.Stats != nil {
+		opts.Stats.Instructions = codeWriter.instructions
+		opts.Stats.Optimized = codeWriter.optimized
+		if instructions >= 0 {
+			opts.Stats.Instructions = instructions
+		}
+	}
+	return nil
+}
+
+// checkStage1 reports every command that is not part of ProfileStage1
+func checkStage1(modules []Module) error {
+	var diagnostics DiagnosticList
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if !stage1Commands[c.Type] {
+				name := strings.Fields(c.Line)[0]
+				diagnostics.Add(newDiagnostic(c.Pos, name, "command is not supported by the %s profile", ProfileStage1))
+			}
+		}
+	}
+	return diagnostics.Err()
+}
+
+// definesFunction reports whether any of the modules declares functionName
+func definesFunction(modules []Module, functionName string) bool {
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type == C_FUNCTION && c.Arg1 == functionName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeModule writes the commands of module and returns the errors found
+func (c *codeWriter) writeModule(module Module) error {
+	var diagnostics DiagnosticList
+	c.setFileName(module.Name)
+	for _, cmd := range module.Commands {
+		c.setPosition(cmd.Arg1Pos)
+		function := c.functionName
+		if cmd.Type == C_FUNCTION {
+			function = cmd.Arg1
+		}
+		c.setSource(SourceEntry{File: cmd.Pos.File, Line: cmd.Pos.Line, Command: cmd.String(), Function: function})
+		var err error
+		if cmd.Type == C_ARITHMETIC {
+			err = c.writeArithmetic(cmd.Arg1)
+		} else if cmd.Type == C_PUSH || cmd.Type == C_POP {
+			err = c.writePushPop(cmd.Type, cmd.Arg1, cmd.Arg2)
+		} else if cmd.Type == C_LABEL {
+			c.writeLabel(cmd.Arg1)
+		} else if cmd.Type == C_GOTO {
+			c.writeGoto(cmd.Arg1)
+		} else if cmd.Type == C_IF {
+			c.writeIf(cmd.Arg1)
+		} else if cmd.Type == C_IFNOT {
+			c.writeIfNot(cmd.Arg1)
+		} else if cmd.Type == C_FUNCTION {
+			c.writeFunction(cmd.Arg1, cmd.Arg2)
+		} else if cmd.Type == C_INTRINSIC {
+			c.writeIntrinsic(cmd.Arg1)
+		} else if cmd.Type == C_CALL {
+			c.writeCall(cmd.Arg1, cmd.Arg2)
+		} else if cmd.Type == C_RETURN {
+			c.writeReturn()
+		}
+		diagnostics.Add(err)
+	}
+	// labels are scoped to the functions of one file, the commands before
+	// the first function of the next file are outside of any function
+	c.functionName = ""
+	return diagnostics.Err()
+}
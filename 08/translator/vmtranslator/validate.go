@@ -0,0 +1,108 @@
+package vmtranslator
+
+import "strconv"
+
+// largest value a push constant can load with an A-instruction
+const maxConstant = 32767
+
+// segmentSizes is the number of words of the fixed size segments
+var segmentSizes = map[segment]int{
+	pointer: 2,
+	temp:    8,
+}
+
+// Validate checks the parsed commands before any code is written, so
+// commands that would silently corrupt RAM or jump nowhere are reported
+// with their position
+func Validate(modules []Module) error {
+	var diagnostics DiagnosticList
+	functions := map[string]bool{}
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			if c.Type == C_FUNCTION {
+				functions[c.Arg1] = true
+			}
+		}
+	}
+	for _, module := range modules {
+		for _, c := range module.Commands {
+			switch c.Type {
+			case C_PUSH, C_POP:
+				diagnostics.Add(validatePushPop(c))
+			case C_FUNCTION:
+				if c.Arg2 < 0 {
+					diagnostics.Add(newDiagnostic(c.Arg2Pos, strconv.Itoa(c.Arg2), "number of locals can't be negative"))
+				}
+			case C_CALL:
+				if c.Arg2 < 0 {
+					diagnostics.Add(newDiagnostic(c.Arg2Pos, strconv.Itoa(c.Arg2), "number of arguments can't be negative"))
+				}
+				if !functions[c.Arg1] {
+					diagnostics.Add(newDiagnostic(c.Arg1Pos, c.Arg1, "function is not defined"))
+				}
+			}
+		}
+		diagnostics.Add(validateJumps(module))
+	}
+	return diagnostics.Err()
+}
+
+// validateJumps makes sure every goto and if-goto points to a label defined
+// in the same function. Labels are scoped to their function, the commands
+// before the first function of a file are outside of any function.
+func validateJumps(module Module) error {
+	var diagnostics DiagnosticList
+	function := ""
+	labels := map[string]bool{}
+	var jumps []Command
+	check := func() {
+		for _, c := range jumps {
+			if labels[c.Arg1] {
+				continue
+			}
+			if function == "" {
+				diagnostics.Add(newDiagnostic(c.Arg1Pos, c.Arg1, "label is not defined"))
+			} else {
+				diagnostics.Add(newDiagnostic(c.Arg1Pos, c.Arg1, "label is not defined in function %s", function))
+			}
+		}
+		labels = map[string]bool{}
+		jumps = nil
+	}
+	for _, c := range module.Commands {
+		switch c.Type {
+		case C_FUNCTION:
+			check()
+			function = c.Arg1
+		case C_LABEL:
+			labels[c.Arg1] = true
+		case C_GOTO, C_IF, C_IFNOT:
+			jumps = append(jumps, c)
+		}
+	}
+	check()
+	return diagnostics.Err()
+}
+
+func validatePushPop(c Command) error {
+	seg := segment(c.Arg1)
+	switch seg {
+	case argument, local, static, this, that, pointer, temp:
+	case constant:
+		if c.Type == C_POP {
+			return newDiagnostic(c.Arg1Pos, c.Arg1, "can't pop to the constant segment")
+		}
+	default:
+		return newDiagnostic(c.Arg1Pos, c.Arg1, "unknown segment")
+	}
+	if c.Arg2 < 0 {
+		return newDiagnostic(c.Arg2Pos, strconv.Itoa(c.Arg2), "index can't be negative")
+	}
+	if seg == constant && c.Arg2 > maxConstant {
+		return newDiagnostic(c.Arg2Pos, strconv.Itoa(c.Arg2), "constant must be between 0 and %d", maxConstant)
+	}
+	if size, ok := segmentSizes[seg]; ok && c.Arg2 >= size {
+		return newDiagnostic(c.Arg2Pos, strconv.Itoa(c.Arg2), "%s index must be between 0 and %d", seg, size-1)
+	}
+	return nil
+}
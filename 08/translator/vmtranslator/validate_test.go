@@ -0,0 +1,91 @@
+package vmtranslator
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseModules parses the source of each module, named after the module
+func parseModules(t *testing.T, sources map[string]string) []Module {
+	t.Helper()
+	var modules []Module
+	for _, name := range []string{"Main", "Sys"} {
+		src, ok := sources[name]
+		if !ok {
+			continue
+		}
+		commands, err := Parse(strings.NewReader(src), name+".vm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		modules = append(modules, Module{Name: name, Commands: commands})
+	}
+	return modules
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"valid", "function Main.f 1\nlabel L\npush local 0\nif-goto L\npush constant 32767\nreturn\n", ""},
+		{"bad segment", "push heap 0\n", `Main.vm:1:6: unknown segment: "heap"`},
+		{"pointer index out of range", "push pointer 2\n", `Main.vm:1:14: pointer index must be between 0 and 1: "2"`},
+		{"temp index out of range", "pop temp 8\n", `Main.vm:1:10: temp index must be between 0 and 7: "8"`},
+		{"constant out of range", "push constant 32768\n", `Main.vm:1:15: constant must be between 0 and 32767: "32768"`},
+		{"pop constant", "pop constant 3\n", `Main.vm:1:5: can't pop to the constant segment: "constant"`},
+		{"negative locals", "function Main.f -1\n", `Main.vm:1:17: number of locals can't be negative: "-1"`},
+		{"undefined function", "function Main.f 0\ncall Main.g 0\n", `Main.vm:2:6: function is not defined: "Main.g"`},
+		{"undefined label", "function Main.f 0\ngoto NOWHERE\n",
+			`Main.vm:2:6: label is not defined in function Main.f: "NOWHERE"`},
+		{"label in another function", "function Main.f 0\nlabel L\nfunction Main.g 0\nif-goto L\n",
+			`Main.vm:4:9: label is not defined in function Main.g: "L"`},
+		{"label outside a function", "label L\nfunction Main.f 0\ngoto L\n",
+			`Main.vm:3:6: label is not defined in function Main.f: "L"`},
+		{"undefined label outside a function", "goto L\n", `Main.vm:1:6: label is not defined: "L"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Validate(parseModules(t, map[string]string{"Main": test.src}))
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestValidateFiles checks that labels are scoped to their file
+func TestValidateFiles(t *testing.T) {
+	modules := parseModules(t, map[string]string{
+		"Main": "function Main.f 0\nlabel L\npush constant 0\nreturn\n",
+		"Sys":  "goto L\nfunction Sys.init 0\nlabel END\ngoto END\n",
+	})
+	err := Validate(modules)
+	if want := `Sys.vm:1:6: label is not defined: "L"`; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
+
+// TestValidateAll checks that an undefined label is reported together with
+// the other errors of the program
+func TestValidateAll(t *testing.T) {
+	src := "function Main.f 0\n" +
+		"push heap 0\n" +
+		"pop constant 1\n" +
+		"push temp 9\n" +
+		"call Main.g 0\n" +
+		"goto NOWHERE\n"
+	err := Validate(parseModules(t, map[string]string{"Main": src}))
+	list, ok := err.(DiagnosticList)
+	if !ok || len(list) != 5 {
+		t.Fatalf("got %v, want 5 diagnostics", err)
+	}
+	if last := list[len(list)-1].Error(); last != `Main.vm:6:6: label is not defined in function Main.f: "NOWHERE"` {
+		t.Errorf("last diagnostic: got %s", last)
+	}
+}
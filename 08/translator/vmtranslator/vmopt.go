@@ -0,0 +1,199 @@
+package vmtranslator
+
+import "fmt"
+
+// OptimizeVM rewrites the commands of the modules into shorter equivalent
+// ones before they are translated. The result may contain commands the vm
+// language can't express: push constant with a negative value and
+// C_IFNOT. The modules passed in are not changed.
+func OptimizeVM(modules []Module) []Module {
+	optimized := make([]Module, len(modules))
+	for i, module := range modules {
+		optimized[i] = Module{Name: module.Name, Commands: optimizeCommands(module.Commands)}
+	}
+	return optimized
+}
+
+// vmRule looks at the commands starting at cmds[0], if it applies it returns
+// how many of them it replaces and what with
+type vmRule func(cmds []Command) (n int, replacement []Command, ok bool)
+
+// optimizeCommands runs the rules over cmds until none of them applies
+// anymore. Rules only match consecutive commands without labels between
+// them, so nothing can jump into the middle of a rewritten sequence.
+func optimizeCommands(cmds []Command) []Command {
+	rules := []vmRule{
+		foldBinary,
+		foldUnary,
+		removeDoubleUnary,
+		removePushPop,
+		foldConstantIf,
+		jumpIfNot,
+	}
+	for {
+		changed := false
+		var out []Command
+		for i := 0; i < len(cmds); {
+			applied := false
+			for _, rule := range rules {
+				if n, replacement, ok := rule(cmds[i:]); ok {
+					out = append(out, replacement...)
+					i += n
+					applied = true
+					changed = true
+					break
+				}
+			}
+			if !applied {
+				out = append(out, cmds[i])
+				i++
+			}
+		}
+		cmds = out
+		if !changed {
+			return cmds
+		}
+	}
+}
+
+// constantValue returns the value c pushes if it is a push constant
+func constantValue(c Command) (int16, bool) {
+	if c.Type == C_PUSH && c.Arg1 == string(constant) {
+		return int16(c.Arg2), true
+	}
+	return 0, false
+}
+
+// pushConstant returns a push of v positioned at the command it replaces
+func pushConstant(v int16, at Command) Command {
+	return Command{
+		Type:    C_PUSH,
+		Arg1:    string(constant),
+		Arg2:    int(v),
+		Line:    fmt.Sprintf("push constant %d", v),
+		Pos:     at.Pos,
+		Arg1Pos: at.Arg1Pos,
+		Arg2Pos: at.Arg2Pos,
+	}
+}
+
+func isArithmetic(c Command, ops ...string) bool {
+	if c.Type != C_ARITHMETIC {
+		return false
+	}
+	for _, op := range ops {
+		if c.Arg1 == op {
+			return true
+		}
+	}
+	return false
+}
+
+// foldBinary computes `push constant x; push constant y; op`, the result
+// wraps around like on the Hack computer
+func foldBinary(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 3 {
+		return 0, nil, false
+	}
+	x, okX := constantValue(cmds[0])
+	y, okY := constantValue(cmds[1])
+	if !okX || !okY || cmds[2].Type != C_ARITHMETIC {
+		return 0, nil, false
+	}
+	var v int16
+	switch cmds[2].Arg1 {
+	case "add":
+		v = x + y
+	case "sub":
+		v = x - y
+	case "and":
+		v = x & y
+	case "or":
+		v = x | y
+	case "eq":
+		v = vmBool(x == y)
+	case "gt":
+		v = vmBool(x > y)
+	case "lt":
+		v = vmBool(x < y)
+	default:
+		return 0, nil, false
+	}
+	return 3, []Command{pushConstant(v, cmds[0])}, true
+}
+
+// foldUnary computes `push constant x; neg` and `push constant x; not`,
+// `push constant 0; not` becomes a single push of true
+func foldUnary(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 2 {
+		return 0, nil, false
+	}
+	x, ok := constantValue(cmds[0])
+	if !ok || cmds[1].Type != C_ARITHMETIC {
+		return 0, nil, false
+	}
+	switch cmds[1].Arg1 {
+	case "neg":
+		return 2, []Command{pushConstant(-x, cmds[0])}, true
+	case "not":
+		return 2, []Command{pushConstant(^x, cmds[0])}, true
+	}
+	return 0, nil, false
+}
+
+// removeDoubleUnary drops `neg; neg` and `not; not`
+func removeDoubleUnary(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 2 || !isArithmetic(cmds[0], "neg", "not") || !isArithmetic(cmds[1], cmds[0].Arg1) {
+		return 0, nil, false
+	}
+	return 2, nil, true
+}
+
+// removePushPop drops `push x i; pop x i`, which stores a value where it
+// already is
+func removePushPop(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 2 || cmds[0].Type != C_PUSH || cmds[1].Type != C_POP ||
+		cmds[0].Arg1 != cmds[1].Arg1 || cmds[0].Arg2 != cmds[1].Arg2 {
+		return 0, nil, false
+	}
+	return 2, nil, true
+}
+
+// foldConstantIf turns `push constant x; if-goto l` into `goto l` if x isn't
+// 0 and drops it otherwise
+func foldConstantIf(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 2 || cmds[1].Type != C_IF {
+		return 0, nil, false
+	}
+	x, ok := constantValue(cmds[0])
+	if !ok {
+		return 0, nil, false
+	}
+	if x == 0 {
+		return 2, nil, true
+	}
+	jump := cmds[1]
+	jump.Type = C_GOTO
+	jump.Line = "goto " + jump.Arg1
+	return 2, []Command{jump}, true
+}
+
+// jumpIfNot turns `not; if-goto l` into one C_IFNOT command, which jumps
+// unless the value is true (-1). For booleans that is a jump on zero.
+func jumpIfNot(cmds []Command) (int, []Command, bool) {
+	if len(cmds) < 2 || !isArithmetic(cmds[0], "not") || cmds[1].Type != C_IF {
+		return 0, nil, false
+	}
+	jump := cmds[1]
+	jump.Type = C_IFNOT
+	jump.Line = "not; " + jump.Line
+	return 2, []Command{jump}, true
+}
+
+// vmBool is the vm representation of b: -1 for true, 0 for false
+func vmBool(b bool) int16 {
+	if b {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package vmtranslator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"translator/assembler"
+	"translator/emulator"
+)
+
+// formatCommands writes the commands one per line as in a vm file
+func formatCommands(cmds []Command) string {
+	var lines []string
+	for _, c := range cmds {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestOptimizeVM(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"add", "push constant 2\npush constant 3\nadd", "push constant 5"},
+		{"add wraps around", "push constant 32767\npush constant 1\nadd", "push constant -32768"},
+		{"sub wraps around", "push constant 0\npush constant 32767\nsub\npush constant 2\nsub", "push constant 32767"},
+		{"and", "push constant 12\npush constant 10\nand", "push constant 8"},
+		{"or", "push constant 12\npush constant 10\nor", "push constant 14"},
+		{"eq", "push constant 7\npush constant 7\neq", "push constant -1"},
+		{"gt where x-y overflows", "push constant 32767\npush constant 32767\nneg\npush constant 1\nsub\ngt", "push constant -1"},
+		{"lt where x-y overflows", "push constant 32767\npush constant 32767\nneg\npush constant 1\nsub\nlt", "push constant 0"},
+		{"neg", "push constant 32767\nneg", "push constant -32767"},
+		{"neg -32768", "push constant 32767\npush constant 1\nadd\nneg", "push constant -32768"},
+		{"not 0", "push constant 0\nnot", "push constant -1"},
+		{"not", "push constant 5\nnot", "push constant -6"},
+		{"neg neg", "push local 0\nneg\nneg", "push local 0"},
+		{"not not", "push local 0\nnot\nnot\npop local 1", "push local 0\npop local 1"},
+		{"neg not", "push local 0\nneg\nnot", "push local 0\nneg\nnot"},
+		{"push pop", "push local 1\npop local 1", ""},
+		{"push pop other index", "push local 1\npop local 2", "push local 1\npop local 2"},
+		{"push pop other segment", "push local 1\npop argument 1", "push local 1\npop argument 1"},
+		{"if-goto false", "push constant 0\nif-goto L\nlabel L", "label L"},
+		{"if-goto true", "push constant 3\nif-goto L\nlabel L", "goto L\nlabel L"},
+		{"not if-goto", "push local 0\nnot\nif-goto L\nlabel L", "push local 0\nnot; if-goto L\nlabel L"},
+		{"not if-goto after a folded not", "push constant 0\nnot\nnot\nif-goto L\nlabel L", "push constant -1\nnot; if-goto L\nlabel L"},
+		{"label between", "push constant 1\nlabel L\npush constant 2\nadd", "push constant 1\nlabel L\npush constant 2\nadd"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmds, err := Parse(strings.NewReader(test.in), "T.vm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			modules := []Module{{Name: "T", Commands: cmds}}
+			got := formatCommands(OptimizeVM(modules)[0].Commands)
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+			if formatCommands(modules[0].Commands) != formatCommands(cmds) {
+				t.Error("the modules passed in were changed")
+			}
+		})
+	}
+}
+
+// TestOptimizeVMRun runs the folded constants, among them the ones that
+// need their own code like -1 and -32768, and C_IFNOT on the emulator. Each
+// case stores its value into the that segment at 1000.
+func TestOptimizeVMRun(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int16
+	}{
+		{"push constant 32767\npush constant 1\nadd", -32768},
+		{"push constant 32767\nneg\npush constant 1\nsub\npush constant 1\nsub", 32767},
+		{"push constant 0\nnot", -1},
+		{"push constant 2\nneg", -2},
+		{"push constant 32767\nneg", -32767},
+		{"push constant 40\npush constant 2\nadd", 42},
+		// not; if-goto jumps unless the value is true: 1 if it jumped, 2 if not
+		{"push constant 0\npop temp 0\npush constant 1\npush temp 0\nnot\nif-goto A\npop temp 1\npush constant 2\nlabel A", 1},
+		{"push constant 0\nnot\npop temp 0\npush constant 1\npush temp 0\nnot\nif-goto B\npop temp 1\npush constant 2\nlabel B", 2},
+		{"push constant 5\npop temp 0\npush constant 1\npush temp 0\nnot\nif-goto C\npop temp 1\npush constant 2\nlabel C", 1},
+	}
+	var src strings.Builder
+	src.WriteString("push constant 1000\npop pointer 1\n")
+	for i, test := range tests {
+		fmt.Fprintf(&src, "%s\npop that %d\n", test.src, i)
+	}
+	cmds, err := Parse(strings.NewReader(src.String()), "T.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := []Module{{Name: "T", Commands: cmds}}
+	var asm bytes.Buffer
+	if err := Translate(modules, &asm, Options{Bootstrap: BootstrapNever, OptimizeVM: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"@32767\nD=-A\nD=D-1\n", "D=-1\n"} {
+		if !strings.Contains(asm.String(), want) {
+			t.Errorf("no %q in the translation", want)
+		}
+	}
+	code, err := assembler.Assemble(&asm, "T.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	computer, err := emulator.New(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	computer.Poke(0, 256)
+	if err := computer.Run(100000); err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		if got := computer.Peek(1000 + i); got != test.want {
+			t.Errorf("%q: got %d, want %d", test.src, got, test.want)
+		}
+	}
+}